@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fyne.io/fyne/dialog"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+)
+
+// Apply changes to the network (if it is running) after user confirmation and show network screen
+func (app *App) ApplyToRunning(ntw *network.Network, mode applyMode) {
+	worker := app.Pool.Find(ntw.Name())
+	if mode == applyNone || worker == nil {
+		app.ShowNetworkScreen(ntw)
+		return
+	}
+	message := "Configuration changed. Reload network now?"
+	if mode == applyRestart {
+		message = "Configuration changes require restart. Restart network now?"
+	}
+	dialog.ShowConfirm("Network is running", message, func(ok bool) {
+		if ok {
+			app.applyTo(ntw, worker, mode)
+		}
+		app.ShowNetworkScreen(ntw)
+	}, app.Window)
+}
+
+func (app *App) applyTo(ntw *network.Network, worker internal.Port, mode applyMode) {
+	if mode == applyReload {
+		_, err := worker.API().Reload(app.Ctx)
+		if err == nil {
+			return
+		}
+		log.Println("reload", ntw.Name(), err, "- restarting")
+	}
+	restartDialog := dialog.NewProgressInfinite("Restarting", "restarting... ", app.Window)
+	restartDialog.Show()
+	_, err := app.Pool.Restart(app.Ctx, ntw.Name())
+	restartDialog.Hide()
+	if err != nil {
+		log.Println("restart", ntw.Name(), err)
+		dialog.NewInformation("Failed to restart", err.Error(), app.Window).Show()
+	}
+}
+
+type applyMode int
+
+const (
+	applyNone    applyMode = iota // nothing to do
+	applyReload                   // tincd can re-read files by signal
+	applyRestart                  // listening port and device are applied only on start
+)
+
+func classifyUpgrade(self *network.Node, config *network.Config, upgrade network.Upgrade) applyMode {
+	if upgrade.Port != 0 && upgrade.Port != config.Port {
+		return applyRestart
+	}
+	if upgrade.Device != "" && upgrade.Device != config.Device {
+		return applyRestart
+	}
+	if upgrade.Address != nil && !sameAddresses(self.Address, upgrade.Address) {
+		return applyReload
+	}
+	return applyNone
+}
+
+func sameAddresses(a, b []network.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/diag"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/keys"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/support"
+	"github.com/tinc-boot/tincd/network"
+	"path/filepath"
+)
+
+// CLI commands. If command specified it will be executed instead of GUI
+type Commands struct {
+	RotateKeys rotateKeysCommand `command:"rotate-keys" description:"Generate new key pair for self node of network (-n)"`
+	Diagnose   diagnoseCommand   `command:"diagnose" description:"Check reachability of peers of network (-n), requires root"`
+	Support    supportCommand    `command:"support-bundle" description:"Create archive with logs and sanitized configuration for troubleshooting"`
+	Run        foregroundCommand `command:"run" description:"Run network (-n) in foreground, requires root"`
+	Service    serviceCommand    `command:"service" description:"Show, install or remove systemd service of network (-n)"`
+}
+
+func runCommand(ctx context.Context, cfg Config, name string) error {
+	switch name {
+	case "rotate-keys":
+		return cfg.RotateKeys.run(ctx, cfg)
+	case "diagnose":
+		return cfg.Diagnose.run(ctx, cfg)
+	case "support-bundle":
+		return cfg.Support.run(ctx, cfg)
+	case "run":
+		return cfg.Run.run(ctx, cfg)
+	case "service":
+		return cfg.Service.run(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown command %s", name)
+	}
+}
+
+func (cfg *Config) network() (*network.Network, error) {
+	if cfg.Network == "" {
+		return nil, errors.New("network name (-n) is not specified")
+	}
+	ntw := &network.Network{Root: filepath.Join(cfg.ConfigDir, cfg.Network)}
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", cfg.Network)
+	}
+	return ntw, nil
+}
+
+type rotateKeysCommand struct {
+	Push    string `long:"push" description:"Join URL of majordomo server to push new host file"`
+	Export  string `long:"export" description:"File to export new host file"`
+	Confirm bool   `long:"confirm" description:"Confirm previous rotation and remove old key"`
+	Revert  bool   `long:"revert" description:"Revert previous rotation and restore old key"`
+}
+
+func (cmd *rotateKeysCommand) run(ctx context.Context, cfg Config) error {
+	ntw, err := cfg.network()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cmd.Confirm:
+		return keys.Confirm(ntw)
+	case cmd.Revert:
+		return revertKeys(ntw)
+	}
+	self, err := rotateKeys(ntw)
+	if err != nil {
+		return err
+	}
+	fmt.Println("new key generated for", self.Name, "version", self.Version)
+	if cmd.Export != "" {
+		if err := exportSelfToFile(ntw, cmd.Export); err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		fmt.Println("host file exported to", cmd.Export)
+	}
+	if cmd.Push != "" {
+		changed, err := pushSelf(ctx, ntw, cmd.Push)
+		if err != nil {
+			return fmt.Errorf("push: %w", err)
+		}
+		fmt.Println("host file pushed")
+		for _, name := range changed {
+			fmt.Println("WARNING: key of verified peer", name, "changed")
+		}
+	}
+	fmt.Println("old key is kept until rotation confirmed (--confirm) or reverted (--revert)")
+	return nil
+}
+
+type diagnoseCommand struct {
+	Peer  []string `long:"peer" description:"Peer name to check (all known peers by default)"`
+	Count int      `long:"count" description:"Number of echo requests" default:"5"`
+}
+
+func (cmd *diagnoseCommand) run(ctx context.Context, cfg Config) error {
+	ntw, err := cfg.network()
+	if err != nil {
+		return err
+	}
+	peers := cmd.Peer
+	if len(peers) == 0 {
+		config, err := ntw.Read()
+		if err != nil {
+			return err
+		}
+		nodes, err := ntw.Nodes()
+		if err != nil {
+			return err
+		}
+		for _, name := range nodes {
+			if name != config.Name {
+				peers = append(peers, name)
+			}
+		}
+	}
+	for _, peer := range peers {
+		result, err := diag.Node(ctx, ntw, peer, cmd.Count)
+		if err != nil {
+			return fmt.Errorf("%s: %w", peer, err)
+		}
+		fmt.Println(result)
+	}
+	return nil
+}
+
+type supportCommand struct {
+	Output string `short:"o" long:"output" description:"Archive file (support-<time>.zip in config dir by default)"`
+}
+
+func (cmd *supportCommand) run(ctx context.Context, cfg Config) error {
+	bundle := &support.Bundle{ConfigDir: cfg.ConfigDir, Version: version}
+	file := cmd.Output
+	if file == "" {
+		file = bundle.DefaultFile()
+	}
+	if err := bundle.Save(ctx, file); err != nil {
+		return err
+	}
+	fmt.Println("support bundle saved to", file)
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/service"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+type foregroundCommand struct {
+	APIPort int `long:"api-port" description:"Port for worker API on localhost (disabled by default)"`
+}
+
+// Run network (-n) in foreground until interrupted. Used by system services
+func (cmd *foregroundCommand) run(ctx context.Context, cfg Config) error {
+	ntw, err := cfg.network()
+	if err != nil {
+		return err
+	}
+	logger, closer, err := cfg.Log.Open(logging.NetworkFile(cfg.ConfigDir, cfg.Network), os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	return runNetwork(ctx, cmd.APIPort, "", ntw.Root, cfg.Debug, cfg.Netns, logger.With("network", cfg.Network), &logging.Buffer{})
+}
+
+type serviceCommand struct {
+	Install   bool   `long:"install" description:"Install, enable and start systemd service for network, requires root"`
+	Uninstall bool   `long:"uninstall" description:"Stop, disable and remove systemd service for network, requires root"`
+	Mode      string `long:"mode" description:"What service runs: worker (tinc-desktop) or tincd directly" choice:"worker" choice:"tincd" default:"worker"`
+}
+
+// Manage system service of network (-n). Without flags prints service state
+func (cmd *serviceCommand) run(ctx context.Context, cfg Config) error {
+	ntw, err := cfg.network()
+	if err != nil {
+		return err
+	}
+	unit, err := serviceUnit(cfg.ConfigDir, service.Mode(cmd.Mode))
+	if err != nil {
+		return err
+	}
+	switch {
+	case cmd.Install:
+		if unit.Mode == service.ModeTincd {
+			level := settings.DefaultDebugLevel
+			if ns, err := settings.LoadNetwork(ntw); err == nil {
+				level = ns.DebugLevel
+			}
+			if err := service.WriteEnvironment(cfg.ConfigDir, cfg.Network, level); err != nil {
+				return err
+			}
+		}
+		if err := service.Install(unit, cfg.Network); err != nil {
+			return err
+		}
+		fmt.Println("service", unit.InstanceName(cfg.Network), "installed and started")
+		return nil
+	case cmd.Uninstall:
+		if err := service.Uninstall(unit, cfg.Network); err != nil {
+			return err
+		}
+		fmt.Println("service", unit.InstanceName(cfg.Network), "removed")
+		return nil
+	}
+	state, enabled, err := service.Status(unit, cfg.Network)
+	if err != nil {
+		return err
+	}
+	fmt.Println(unit.InstanceName(cfg.Network), state, "enabled:", enabled)
+	return nil
+}
+
+func serviceUnit(configDir string, mode service.Mode) (*service.Unit, error) {
+	dir, err := filepath.Abs(configDir)
+	if err != nil {
+		return nil, err
+	}
+	var binary string
+	if mode == service.ModeTincd {
+		binary, err = exec.LookPath("tincd")
+	} else {
+		binary, err = os.Executable()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &service.Unit{Mode: mode, Executable: binary, ConfigDir: dir}, nil
+}
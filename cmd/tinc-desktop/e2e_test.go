@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/api"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/fakes"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/spawners"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
+	"github.com/tinc-boot/tinc-desktop/sudo"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Test binary is executable of spawned workers and helper: with the variables it runs application
+// with fake tincd instead of tests
+const (
+	workerEnv = "TINC_DESKTOP_TEST_WORKER"
+	peersEnv  = "TINC_DESKTOP_TEST_PEERS"
+	hangEnv   = "TINC_DESKTOP_TEST_HANG"
+	promptEnv = "TINC_DESKTOP_TEST_PROMPT" // file for PID of worker which waits as escalation prompt
+)
+
+const waitTimeout = 15 * time.Second
+
+var (
+	testPeers  = []string{"alice", "bob"}
+	escalation = &fakes.Escalation{}
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(workerEnv) != "" {
+		if file := os.Getenv(promptEnv); file != "" {
+			_ = ioutil.WriteFile(file, []byte(strconv.Itoa(os.Getpid())), 0600)
+			time.Sleep(time.Minute)
+			os.Exit(1)
+		}
+		starter := &fakes.Starter{Peers: strings.Fields(os.Getenv(peersEnv)), Hang: os.Getenv(hangEnv) != ""}
+		starter.Install()
+		main()
+		os.Exit(0)
+	}
+	// inherited by workers even if escalation is skipped (tests are running by root)
+	_ = os.Setenv(workerEnv, "1")
+	_ = os.Setenv(peersEnv, strings.Join(testPeers, " "))
+	_ = os.Setenv("KEEP_ROOT", "1")
+	restore := sudo.Register(escalation)
+	code := m.Run()
+	restore()
+	os.Exit(code)
+}
+
+func configDir(t *testing.T, networks ...string) (string, func()) {
+	dir, err := ioutil.TempDir("", "tinc-desktop-e2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range networks {
+		if _, err := fakes.CreateNetwork(dir, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }
+}
+
+func waitState(t *testing.T, mgr *manager.Manager, name string, expected manager.State) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		state, err := mgr.State(name)
+		if state == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("network %s is %s (error: %v), expected %s", name, state, err, expected)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// checks shared by spawners: worker is reachable by API, recorded in state file and stopped by manager
+func checkWorker(t *testing.T, mgr *manager.Manager, dir string, name string) {
+	t.Helper()
+	port, err := mgr.Spawn(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, mgr, name, manager.Running)
+	peers, err := port.API().Peers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(peers, testPeers) {
+		t.Errorf("peers %v, expected %v", peers, testPeers)
+	}
+	state, err := workerstate.Read(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.PID == os.Getpid() || state.Token == "" {
+		t.Errorf("unexpected worker state %+v", state)
+	}
+	// token is sent only to authorized host
+	anonymous := strings.Replace(state.Endpoint, "127.0.0.1", "localhost", 1)
+	if _, err := (&api.WorkerClient{BaseURL: anonymous}).Peers(context.Background()); err == nil {
+		t.Error("worker API is available without token")
+	}
+
+	step, err := mgr.Stop(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != internal.StepForced {
+		t.Errorf("stop step %s, expected %s", step, internal.StepForced)
+	}
+	waitState(t, mgr, name, manager.Stopped)
+	if _, err := workerstate.Read(filepath.Join(dir, name)); !os.IsNotExist(err) {
+		t.Errorf("worker state is not removed: %v", err)
+	}
+}
+
+func TestRunner_API(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	defer (&fakes.Starter{Peers: testPeers}).Install()()
+	directory := filepath.Join(dir, "net1")
+	logger := logging.New(ioutil.Discard, logging.FormatLogfmt, logging.LevelDebug)
+
+	port := freePort(t)
+	exited := make(chan error, 1)
+	go func() {
+		exited <- runNetwork(context.Background(), port, "secret", directory, 0, "", logger, &logging.Buffer{})
+	}()
+	workerstate.Authorize("http://127.0.0.1:"+strconv.Itoa(port), "secret")
+	client := &api.WorkerClient{BaseURL: "http://127.0.0.1:" + strconv.Itoa(port)}
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		peers, err := client.Peers(context.Background())
+		if err == nil {
+			if !reflect.DeepEqual(peers, testPeers) {
+				t.Errorf("peers %v, expected %v", peers, testPeers)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	workerstate.Authorize("http://localhost:"+strconv.Itoa(port), "guess")
+	wrongToken := &api.WorkerClient{BaseURL: "http://localhost:" + strconv.Itoa(port)}
+	if _, err := wrongToken.Peers(context.Background()); err == nil {
+		t.Error("request with wrong token is accepted")
+	} else if strings.Contains(err.Error(), "guess") {
+		t.Errorf("token is leaked by error: %v", err)
+	}
+	state, err := workerstate.Read(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.PID != os.Getpid() || state.Token != "secret" || state.Endpoint != client.BaseURL {
+		t.Errorf("unexpected worker state %+v", state)
+	}
+	if err := runNetwork(context.Background(), freePort(t), "", directory, 0, "", logger, &logging.Buffer{}); !errors.Is(err, netlock.ErrLocked) {
+		t.Errorf("second runner of network should fail by lock, got %v", err)
+	}
+
+	step, err := client.Stop(context.Background(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != internal.StepForced {
+		t.Errorf("stop step %s, expected %s", step, internal.StepForced)
+	}
+	select {
+	case err := <-exited:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("runner is not exited")
+	}
+	if _, err := workerstate.Read(directory); !os.IsNotExist(err) {
+		t.Errorf("worker state is not removed: %v", err)
+	}
+}
+
+func TestSameProcess(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	starter := &fakes.Starter{Peers: testPeers}
+	defer starter.Install()()
+	mgr := &manager.Manager{Spawner: &spawners.SameProcess{ConfigLocation: dir, Logging: fakes.Logging}}
+
+	port, err := mgr.Spawn(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, mgr, "net1", manager.Running)
+	if peers, err := port.API().Peers(context.Background()); err != nil || !reflect.DeepEqual(peers, testPeers) {
+		t.Errorf("peers %v (%v), expected %v", peers, err, testPeers)
+	}
+	if _, err := mgr.Stop(context.Background(), "net1"); err != nil {
+		t.Fatal(err)
+	}
+	if instances := starter.Started(); len(instances) != 1 || instances[0].IsRunning() {
+		t.Error("tincd is not stopped")
+	}
+}
+
+func TestSubProcess(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	escalated := escalation.Count()
+	mgr := &manager.Manager{Spawner: &spawners.SubProcess{ConfigLocation: dir, Logging: fakes.Logging, Helper: fakes.EscalationName}}
+
+	checkWorker(t, mgr, dir, "net1")
+	// root doesn't need escalation
+	if os.Geteuid() != 0 && escalation.Count() != escalated+1 {
+		t.Errorf("worker is escalated %d times, expected once", escalation.Count()-escalated)
+	}
+}
+
+func TestSharedHelper(t *testing.T) {
+	dir, cleanup := configDir(t, "net1", "net2")
+	defer cleanup()
+	escalated := escalation.Count()
+	helper := &spawners.SharedHelper{ConfigLocation: dir, Logging: fakes.Logging, Helper: fakes.EscalationName}
+	defer helper.Close()
+	mgr := &manager.Manager{Spawner: helper}
+
+	checkWorker(t, mgr, dir, "net1")
+	checkWorker(t, mgr, dir, "net2")
+	// credentials are asked once for all networks
+	if os.Geteuid() != 0 && escalation.Count() != escalated+1 {
+		t.Errorf("helper is escalated %d times, expected once", escalation.Count()-escalated)
+	}
+	if _, err := mgr.Spawn(context.Background(), "../net1"); err == nil {
+		t.Error("helper should reject invalid network name")
+	}
+}
+
+func TestAdoptOrphanedWorker(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	spawner := &spawners.SubProcess{ConfigLocation: dir, Logging: fakes.Logging, Helper: fakes.EscalationName}
+	crashed := &manager.Manager{Spawner: spawner}
+	orphan, err := crashed.Spawn(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, crashed, "net1", manager.Running)
+
+	// next instance of application
+	mgr := &manager.Manager{Spawner: spawner}
+	mgr.Adopt(context.Background(), []string{"net1"})
+	waitState(t, mgr, "net1", manager.Running)
+	if _, err := mgr.Spawn(context.Background(), "net1"); err != nil {
+		t.Errorf("adopted worker should be reused: %v", err)
+	}
+	if _, err := mgr.Stop(context.Background(), "net1"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-orphan.Done():
+	case <-time.After(waitTimeout):
+		t.Fatal("adopted worker is not stopped")
+	}
+
+	// crash of adopted worker is a failure
+	if _, err := crashed.Spawn(context.Background(), "net1"); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, crashed, "net1", manager.Running)
+	mgr = &manager.Manager{Spawner: spawner}
+	mgr.Adopt(context.Background(), []string{"net1"})
+	waitState(t, mgr, "net1", manager.Running)
+	state, err := workerstate.Read(filepath.Join(dir, "net1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := os.FindProcess(state.PID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := worker.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, mgr, "net1", manager.Failed)
+
+	// state of crashed worker is removed
+	// exit code of help is not important, only PID is needed
+	exited := exec.Command(os.Args[0], "-h")
+	_ = exited.Run()
+	stale := &workerstate.State{PID: exited.Process.Pid, Endpoint: "http://127.0.0.1:1", Token: "stale"}
+	if err := workerstate.Write(filepath.Join(dir, "net1"), stale); err != nil {
+		t.Fatal(err)
+	}
+	mgr.Adopt(context.Background(), []string{"net1"})
+	if mgr.Find("net1") != nil {
+		t.Error("exited worker is adopted")
+	}
+	if _, err := workerstate.Read(filepath.Join(dir, "net1")); !os.IsNotExist(err) {
+		t.Errorf("stale worker state is not removed: %v", err)
+	}
+}
+
+func TestHungWorkerTerminated(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	_ = os.Setenv(hangEnv, "1")
+	defer os.Unsetenv(hangEnv)
+	mgr := &manager.Manager{
+		Spawner:     &spawners.SubProcess{ConfigLocation: dir, Logging: fakes.Logging, Helper: fakes.EscalationName},
+		StopTimeout: 200 * time.Millisecond,
+	}
+	if _, err := mgr.Spawn(context.Background(), "net1"); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, mgr, "net1", manager.Running)
+
+	step, err := mgr.Stop(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != internal.StepTerminated {
+		t.Errorf("stop step %s, expected %s", step, internal.StepTerminated)
+	}
+	waitState(t, mgr, "net1", manager.Stopped)
+}
+
+func TestSpawnCancelledDuringEscalation(t *testing.T) {
+	dir, cleanup := configDir(t, "net1")
+	defer cleanup()
+	pidFile := filepath.Join(dir, "prompt.pid")
+	_ = os.Setenv(promptEnv, pidFile)
+	defer os.Unsetenv(promptEnv)
+	mgr := &manager.Manager{Spawner: &spawners.SubProcess{ConfigLocation: dir, Logging: fakes.Logging, Helper: fakes.EscalationName}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			if _, err := os.Stat(pidFile); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+	if _, err := mgr.Spawn(ctx, "net1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancel error, got %v", err)
+	}
+	waitState(t, mgr, "net1", manager.Stopped)
+
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(waitTimeout)
+	for process.Signal(syscall.Signal(0)) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("escalated process is not killed after cancel")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestJoinByMajordomo(t *testing.T) {
+	dir, cleanup := configDir(t)
+	defer cleanup()
+	peerDir, peerCleanup := configDir(t, "shared")
+	defer peerCleanup()
+	peerNetwork := &network.Network{Root: filepath.Join(peerDir, "shared")}
+	peer, err := peerNetwork.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	majordomo, err := fakes.NewMajordomo("shared", fakes.Subnet, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer majordomo.Close()
+	app := &App{Config: Config{ConfigDir: dir}, Settings: settings.DefaultApp()}
+
+	ntw, err := app.joinNetwork(context.Background(), majordomo.JoinURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ntw.Name() != "shared" {
+		t.Errorf("joined network %s", ntw.Name())
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined := majordomo.Joined(); len(joined) != 1 || joined[0].Name != self.Name {
+		t.Errorf("self node is not sent to majordomo: %v", joined)
+	}
+	if _, err := ntw.Node(peer.Name); err != nil {
+		t.Errorf("host of peer is not imported: %v", err)
+	}
+	ns, err := settings.LoadNetwork(ntw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.JoinURL != majordomo.JoinURL() {
+		t.Errorf("join URL %q is not saved", ns.JoinURL)
+	}
+	if !reflect.DeepEqual(app.Settings.MajordomoServers, []string{majordomo.URL()}) {
+		t.Errorf("majordomo servers %v, expected %s", app.Settings.MajordomoServers, majordomo.URL())
+	}
+
+	if _, err := app.joinNetwork(context.Background(), majordomo.URL()+"/invalid"); err == nil {
+		t.Error("invalid join URL is accepted")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reddec/jsonrpc2"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/helperapi"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/spawners"
+	"github.com/tinc-boot/tincd/network"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const parentCheckInterval = 2 * time.Second
+
+// Run privileged helper which spawns workers of networks on request until shutdown, interruption or exit of
+// parent application
+func runHelper(global context.Context, cfg Config, token string, logger *logging.Logger) error {
+	if token == "" {
+		// helper runs networks as root, so anonymous local users must not control it
+		return errors.New("auth token of helper API is required")
+	}
+	ctx, cancel := context.WithCancel(global)
+	defer cancel()
+
+	srv := &helperServer{
+		ConfigDir: cfg.ConfigDir,
+		Pool:      &manager.Manager{Spawner: &spawners.SubProcess{ConfigLocation: cfg.ConfigDir, Logging: cfg.Log, Direct: true}},
+		shutdown:  cancel,
+	}
+	var router jsonrpc2.Router
+	helperapi.RegisterHelper(&router, srv)
+	server := &http.Server{Addr: "127.0.0.1:" + strconv.Itoa(cfg.HelperPort), Handler: requireToken(token, jsonrpc2.HandlerRestContext(ctx, &router))}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed start helper API", "error", err)
+		}
+		cancel()
+	}()
+	if cfg.ParentPID != 0 {
+		go watchParent(ctx, cfg.ParentPID, cancel)
+	}
+	logger.Info("privileged helper started", "port", cfg.HelperPort)
+
+	<-ctx.Done()
+	if err := srv.Pool.StopAll(context.Background()); err != nil {
+		logger.Error("failed stop networks", "error", err)
+	}
+	return server.Close()
+}
+
+func watchParent(ctx context.Context, pid int, stop func()) {
+	ticker := time.NewTicker(parentCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !internal.ProcessAlive(pid) {
+			stop()
+			return
+		}
+	}
+}
+
+type helperServer struct {
+	ConfigDir string
+	Pool      *manager.Manager
+	shutdown  func()
+}
+
+func (hs *helperServer) Start(ctx context.Context, name string) (*internal.Endpoint, error) {
+	if !network.IsValidName(name) {
+		return nil, fmt.Errorf("invalid network name %q", name)
+	}
+	if !(&network.Network{Root: filepath.Join(hs.ConfigDir, name)}).IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", name)
+	}
+	worker, err := hs.Pool.Spawn(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, ok := worker.(interface{ Endpoint() *internal.Endpoint })
+	if !ok {
+		return nil, errors.New("worker has no API endpoint")
+	}
+	return endpoint.Endpoint(), nil
+}
+
+func (hs *helperServer) Wait(ctx context.Context, name string) (string, error) {
+	worker := hs.Pool.Find(name)
+	if worker == nil {
+		// exited before request
+		if err := hs.Pool.LastError(name); err != nil {
+			return err.Error(), nil
+		}
+		return "", nil
+	}
+	select {
+	case <-worker.Done():
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if err := worker.Error(); err != nil {
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+func (hs *helperServer) Terminate(ctx context.Context, name string) (bool, error) {
+	if err := hs.Pool.Terminate(name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (hs *helperServer) Shutdown(ctx context.Context) (bool, error) {
+	hs.shutdown()
+	return true, nil
+}
@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	client "github.com/reddec/jsonrpc2/client"
+	internal "github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
 	"sync/atomic"
+	"time"
 )
 
 func Default() *WorkerClient {
@@ -21,8 +23,41 @@ func (impl *WorkerClient) Kill(ctx context.Context) (reply bool, err error) {
 	return
 }
 
+/*
+Stop tincd gracefully (by signal, so tinc-down is executed) and kill it if it is not stopped in timeout.
+Returns step which stopped tincd
+*/
+func (impl *WorkerClient) Stop(ctx context.Context, timeout time.Duration) (reply internal.StopStep, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.Stop", atomic.AddUint64(&impl.sequence, 1), &reply, timeout)
+	return
+}
+
 //
 func (impl *WorkerClient) Peers(ctx context.Context) (reply []string, err error) {
 	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.Peers", atomic.AddUint64(&impl.sequence, 1), &reply)
 	return
 }
+
+// Reload configuration and host files without restart
+func (impl *WorkerClient) Reload(ctx context.Context) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.Reload", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
+
+// Check reachability of peer by name: latency, loss and path MTU
+func (impl *WorkerClient) Diagnose(ctx context.Context, node string) (reply *internal.Diagnostics, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.Diagnose", atomic.AddUint64(&impl.sequence, 1), &reply, node)
+	return
+}
+
+// Worker and tincd log lines after provided ID. Waits for new lines if there are no such lines yet
+func (impl *WorkerClient) Logs(ctx context.Context, after uint64) (reply []internal.LogLine, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.Logs", atomic.AddUint64(&impl.sequence, 1), &reply, after)
+	return
+}
+
+// Change tincd debug level without restart
+func (impl *WorkerClient) SetDebugLevel(ctx context.Context, level int) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Worker.SetDebugLevel", atomic.AddUint64(&impl.sequence, 1), &reply, level)
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	jsonrpc2 "github.com/reddec/jsonrpc2"
 	internal "github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"time"
 )
 
 func RegisterWorker(router *jsonrpc2.Router, wrap internal.Worker) []string {
@@ -24,6 +25,22 @@ func RegisterWorker(router *jsonrpc2.Router, wrap internal.Worker) []string {
 		return wrap.Kill(ctx)
 	})
 
+	router.RegisterFunc("Worker.Stop", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 time.Duration `json:"timeout"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Stop(ctx, args.Arg0)
+	})
+
 	router.RegisterFunc("Worker.Peers", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
 		var args struct{}
 		var err error
@@ -38,5 +55,67 @@ func RegisterWorker(router *jsonrpc2.Router, wrap internal.Worker) []string {
 		return wrap.Peers(ctx)
 	})
 
-	return []string{"Worker.Kill", "Worker.Peers"}
+	router.RegisterFunc("Worker.Reload", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Reload(ctx)
+	})
+
+	router.RegisterFunc("Worker.Diagnose", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"node"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Diagnose(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("Worker.Logs", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 uint64 `json:"after"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Logs(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("Worker.SetDebugLevel", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 int `json:"level"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.SetDebugLevel(ctx, args.Arg0)
+	})
+
+	return []string{"Worker.Kill", "Worker.Stop", "Worker.Peers", "Worker.Reload", "Worker.Diagnose", "Worker.Logs", "Worker.SetDebugLevel"}
 }
@@ -0,0 +1,105 @@
+package autostart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+const (
+	Name  = "tinc-desktop"
+	Label = "io.github.tinc-boot.tinc-desktop" // launchd label
+)
+
+// Command started on user login
+type Entry struct {
+	Executable string
+	Args       []string
+	Comment    string
+}
+
+// Entry for current executable
+func Current() (*Entry, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Executable: executable, Comment: "Tinc VPN desktop client"}, nil
+}
+
+// XDG autostart desktop entry (Linux and other freedesktop environments)
+func (e *Entry) DesktopEntry() []byte {
+	var out bytes.Buffer
+	out.WriteString("[Desktop Entry]\n")
+	out.WriteString("Type=Application\n")
+	out.WriteString("Name=" + Name + "\n")
+	out.WriteString("Comment=" + e.Comment + "\n")
+	out.WriteString("Exec=" + e.desktopExec() + "\n")
+	out.WriteString("Terminal=false\n")
+	out.WriteString("X-GNOME-Autostart-enabled=true\n")
+	return out.Bytes()
+}
+
+// launchd agent definition (macOS)
+func (e *Entry) LaunchAgent() []byte {
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	out.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	out.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	out.WriteString("\t<key>Label</key>\n\t<string>" + escapeXML(Label) + "</string>\n")
+	out.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{e.Executable}, e.Args...) {
+		out.WriteString("\t\t<string>" + escapeXML(arg) + "</string>\n")
+	}
+	out.WriteString("\t</array>\n")
+	out.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	out.WriteString("</dict>\n</plist>\n")
+	return out.Bytes()
+}
+
+// Command line for Run registry value (Windows)
+func (e *Entry) RunCommand() string {
+	var parts []string
+	for _, arg := range append([]string{e.Executable}, e.Args...) {
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.Replace(arg, `"`, `\"`, -1) + `"`
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Registry file with Run value (Windows) for manual import
+func (e *Entry) RegistryFile() []byte {
+	var out bytes.Buffer
+	out.WriteString("Windows Registry Editor Version 5.00\r\n\r\n")
+	out.WriteString("[HKEY_CURRENT_USER\\" + runKey + "]\r\n")
+	value := strings.Replace(strings.Replace(e.RunCommand(), `\`, `\\`, -1), `"`, `\"`, -1)
+	out.WriteString(`"` + Name + `"="` + value + "\"\r\n")
+	return out.Bytes()
+}
+
+const runKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// Exec key quoting by desktop entry specification
+func (e *Entry) desktopExec() string {
+	var parts []string
+	for _, arg := range append([]string{e.Executable}, e.Args...) {
+		if strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`") {
+			arg = strings.Replace(arg, `\`, `\\`, -1)
+			for _, c := range []string{`"`, "`", "$"} {
+				arg = strings.Replace(arg, c, `\`+c, -1)
+			}
+			arg = `"` + arg + `"`
+		}
+		parts = append(parts, strings.Replace(arg, "%", "%%", -1))
+	}
+	return strings.Join(parts, " ")
+}
+
+func escapeXML(text string) string {
+	var out bytes.Buffer
+	_ = xml.EscapeText(&out, []byte(text))
+	return out.String()
+}
@@ -0,0 +1,82 @@
+package autostart
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEntry_DesktopEntry(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		exec string
+	}{
+		{"plain", []string{"/usr/bin/tinc-desktop", "--minimized"}, `/usr/bin/tinc-desktop --minimized`},
+		{"space", []string{"/opt/my apps/tinc-desktop"}, `"/opt/my apps/tinc-desktop"`},
+		{"quote and dollar", []string{`/opt/a"b$c`}, `"/opt/a\"b\$c"`},
+		{"backtick", []string{"/opt/a`b"}, "\"/opt/a\\`b\""},
+		{"backslash", []string{`/opt/back\slash`}, `"/opt/back\\slash"`},
+		{"percent", []string{"/opt/100%/tinc-desktop"}, `/opt/100%%/tinc-desktop`},
+		{"quoted percent", []string{"/opt/a b%"}, `"/opt/a b%%"`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry := &Entry{Executable: c.args[0], Args: c.args[1:]}
+			out := string(entry.DesktopEntry())
+			if !strings.Contains(out, "\nExec="+c.exec+"\n") {
+				t.Errorf("expected Exec=%s in:\n%s", c.exec, out)
+			}
+		})
+	}
+}
+
+func TestEntry_LaunchAgent(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		strings []string
+	}{
+		{"plain", []string{"/Applications/tinc-desktop", "--minimized"}, []string{"/Applications/tinc-desktop", "--minimized"}},
+		{"space", []string{"/Applications/Tinc Desktop.app/tinc-desktop"}, []string{"/Applications/Tinc Desktop.app/tinc-desktop"}},
+		{"markup", []string{"/opt/a&b<c>"}, []string{"/opt/a&amp;b&lt;c&gt;"}},
+		{"quotes", []string{`/opt/"a"'b'`}, []string{"/opt/&#34;a&#34;&#39;b&#39;"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry := &Entry{Executable: c.args[0], Args: c.args[1:]}
+			out := string(entry.LaunchAgent())
+			var expected = "\t<key>ProgramArguments</key>\n\t<array>\n"
+			for _, s := range c.strings {
+				expected += "\t\t<string>" + s + "</string>\n"
+			}
+			expected += "\t</array>\n"
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected arguments:\n%s\nin:\n%s", expected, out)
+			}
+		})
+	}
+}
+
+func TestEntry_RegistryFile(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		value string
+	}{
+		{"plain", []string{`C:\tinc\tinc-desktop.exe`}, `"tinc-desktop"="C:\\tinc\\tinc-desktop.exe"`},
+		{"space", []string{`C:\Program Files\tinc\tinc-desktop.exe`, "--minimized"}, `"tinc-desktop"="\"C:\\Program Files\\tinc\\tinc-desktop.exe\" --minimized"`},
+		{"quote", []string{`C:\tinc\tinc-desktop.exe`, `say "hi"`}, `"tinc-desktop"="C:\\tinc\\tinc-desktop.exe \"say \\\"hi\\\"\""`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry := &Entry{Executable: c.args[0], Args: c.args[1:]}
+			out := string(entry.RegistryFile())
+			if !strings.HasPrefix(out, "Windows Registry Editor Version 5.00\r\n\r\n[HKEY_CURRENT_USER\\"+runKey+"]\r\n") {
+				t.Errorf("unexpected header:\n%s", out)
+			}
+			if !strings.HasSuffix(out, "]\r\n"+c.value+"\r\n") {
+				t.Errorf("expected value %s in:\n%s", c.value, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package autostart
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Install XDG autostart entry for current user
+func Install(e *Entry) error {
+	file, err := location()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, e.DesktopEntry(), 0644)
+}
+
+func Uninstall() error {
+	file, err := location()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func Installed() bool {
+	file, err := location()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(file)
+	return err == nil
+}
+
+func location() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autostart", Name+".desktop"), nil
+}
@@ -0,0 +1,11 @@
+// +build !linux,!darwin,!windows
+
+package autostart
+
+import "errors"
+
+var errUnsupported = errors.New("autostart is not supported on the platform")
+
+func Install(e *Entry) error { return errUnsupported }
+func Uninstall() error       { return errUnsupported }
+func Installed() bool        { return false }
@@ -0,0 +1,31 @@
+package autostart
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Install Run value in registry of current user
+func Install(e *Entry) error {
+	return reg("add", `HKCU\`+runKey, "/v", Name, "/t", "REG_SZ", "/d", e.RunCommand(), "/f")
+}
+
+func Uninstall() error {
+	if !Installed() {
+		return nil
+	}
+	return reg("delete", `HKCU\`+runKey, "/v", Name, "/f")
+}
+
+func Installed() bool {
+	return reg("query", `HKCU\`+runKey, "/v", Name) == nil
+}
+
+func reg(args ...string) error {
+	output, err := exec.Command("reg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"os"
+	"sync"
+	"time"
+)
+
+const controlWait = 5 * time.Second
+
+// Debug level of running tincd. Library starts tincd (tinc 1.0) with level 4 and tinc 1.0 has no control
+// socket: SIGINT switches level 5 on and back, so only levels 4 and 5 could be set
+type DebugLevel struct {
+	pidfile string
+	lock    sync.Mutex
+	raised  bool
+}
+
+func NewDebugLevel(pidfile string) *DebugLevel {
+	return &DebugLevel{pidfile: pidfile}
+}
+
+// Set debug level of tincd. Waits for pidfile of just started tincd
+func (dl *DebugLevel) Set(ctx context.Context, level int) error {
+	raise := settings.ClampDebugLevel(level) == settings.MaxDebugLevel
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+	if raise == dl.raised {
+		return nil
+	}
+	if err := waitFile(ctx, dl.pidfile, controlWait); err != nil {
+		return err
+	}
+	if err := ToggleDebug(dl.pidfile); err != nil {
+		return fmt.Errorf("set debug level %d: %w", level, err)
+	}
+	dl.raised = raise
+	return nil
+}
+
+func waitFile(ctx context.Context, file string, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		if _, err := os.Stat(file); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("%s not created in %v", file, timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
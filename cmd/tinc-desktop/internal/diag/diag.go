@@ -0,0 +1,111 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tincd/network"
+	"time"
+)
+
+const (
+	DefaultCount   = 5
+	DefaultTimeout = time.Second
+	pingInterval   = 200 * time.Millisecond
+	pingPayload    = 56
+)
+
+// Check reachability of network node by name
+func Node(ctx context.Context, ntw *network.Network, name string, count int) (*internal.Diagnostics, error) {
+	info, err := ntw.Node(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IP == "" {
+		return nil, fmt.Errorf("node %s has no VPN IP", name)
+	}
+	return Run(ctx, name, info.IP, count)
+}
+
+// Check reachability of peer by VPN IP: latency, loss and path MTU
+func Run(ctx context.Context, node, ip string, count int) (*internal.Diagnostics, error) {
+	if count <= 0 {
+		count = DefaultCount
+	}
+	p, err := newPinger(ip)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	var ans = &internal.Diagnostics{Node: node, IP: ip}
+	var total time.Duration
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(pingInterval):
+			}
+		}
+		ans.Sent++
+		rtt, err := p.echo(ctx, pingPayload, DefaultTimeout)
+		if err != nil {
+			continue
+		}
+		ans.Received++
+		total += rtt
+		if ans.MinRTT == 0 || rtt < ans.MinRTT {
+			ans.MinRTT = rtt
+		}
+		if rtt > ans.MaxRTT {
+			ans.MaxRTT = rtt
+		}
+	}
+	ans.Loss = float64(ans.Sent-ans.Received) * 100 / float64(ans.Sent)
+	if ans.Received == 0 {
+		return ans, nil
+	}
+	ans.AvgRTT = total / time.Duration(ans.Received)
+
+	mtu, err := p.pathMTU(ctx, DefaultTimeout)
+	if err != nil {
+		ans.MTUError = err.Error()
+	}
+	ans.MTU = mtu
+	return ans, nil
+}
+
+// binary search of largest packet which can be delivered without fragmentation
+func (p *pinger) pathMTU(ctx context.Context, timeout time.Duration) (int, error) {
+	if err := setDontFragment(p.conn); err != nil {
+		return 0, err
+	}
+	low, high := pingPayload, maxMTU-headersSize
+	for low < high {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		mid := (low + high + 1) / 2
+		if p.probe(ctx, mid, timeout) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low + headersSize, nil
+}
+
+// probe with one retry: single lost packet should not be treated as MTU limit
+func (p *pinger) probe(ctx context.Context, payloadSize int, timeout time.Duration) bool {
+	for i := 0; i < 2; i++ {
+		_, err := p.echo(ctx, payloadSize, timeout)
+		if err == nil {
+			return true
+		}
+		if err == errTooLarge {
+			return false
+		}
+	}
+	return false
+}
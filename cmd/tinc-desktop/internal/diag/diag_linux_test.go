@@ -0,0 +1,72 @@
+package diag_test
+
+import (
+	"context"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/diag"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/privileges"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stage of test process: copied test binary drops privileges and relaunched one runs diagnostics
+const stageEnv = "TINC_DESKTOP_TEST_STAGE"
+
+// runner drops root privileges by default, so diagnostics should work with capabilities of worker only
+func TestRunAfterPrivilegeDrop(t *testing.T) {
+	switch os.Getenv(stageEnv) {
+	case "dropped":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		info, err := diag.Run(ctx, "localhost", "127.0.0.1", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Received != 1 {
+			t.Fatalf("no reply: %+v", info)
+		}
+		return
+	case "relaunch":
+		_ = os.Setenv(stageEnv, "dropped")
+		relaunched, err := privileges.Relaunch(privileges.Capabilities(false), func(uid, gid int) error { return nil })
+		if !relaunched {
+			t.Fatal("privileges are not dropped")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if os.Getuid() != 0 || !privileges.HasNetAdmin() {
+		t.Skip("root is required to drop privileges")
+	}
+	// build directory of tests is not accessible for unprivileged user
+	dir, err := ioutil.TempDir("", "tinc-desktop-diag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "diag.test")
+	if err := ioutil.WriteFile(binary, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(binary, "-test.run", "^TestRunAfterPrivilegeDrop$")
+	cmd.Env = append(os.Environ(), stageEnv+"=relaunch", "SUDO_UID=65534")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+}
@@ -0,0 +1,22 @@
+package diag
+
+import (
+	"net"
+	"syscall"
+)
+
+// forbid fragmentation: too large packets will be rejected by kernel (EMSGSIZE) or dropped on the path
+func setDontFragment(conn *net.IPConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var optErr error
+	err = raw.Control(func(fd uintptr) {
+		optErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO)
+	})
+	if err != nil {
+		return err
+	}
+	return optErr
+}
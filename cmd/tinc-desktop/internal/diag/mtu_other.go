@@ -0,0 +1,12 @@
+// +build !linux
+
+package diag
+
+import (
+	"errors"
+	"net"
+)
+
+func setDontFragment(conn *net.IPConn) error {
+	return errors.New("path MTU discovery is supported only on linux")
+}
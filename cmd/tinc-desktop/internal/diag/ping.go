@@ -0,0 +1,99 @@
+package diag
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	icmpEchoRequest = 8
+	icmpEchoReply   = 0
+	headersSize     = 20 + 8 // IPv4 + ICMP headers
+	maxMTU          = 1500
+)
+
+var errTooLarge = errors.New("packet too large")
+
+// Single ICMP echo session. Requires privileges for raw socket
+type pinger struct {
+	conn *net.IPConn
+	ip   net.IP
+	id   uint16
+	seq  uint16
+}
+
+func newPinger(ip string) (*pinger, error) {
+	addr := net.ParseIP(ip).To4()
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IPv4 address %s", ip)
+	}
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("open ICMP socket (root required): %w", err)
+	}
+	return &pinger{conn: conn.(*net.IPConn), ip: addr, id: uint16(rand.Intn(0xffff))}, nil
+}
+
+func (p *pinger) Close() error {
+	return p.conn.Close()
+}
+
+// send echo request with payload of specified size and wait for reply
+func (p *pinger) echo(ctx context.Context, payloadSize int, timeout time.Duration) (time.Duration, error) {
+	p.seq++
+	packet := make([]byte, 8+payloadSize)
+	packet[0] = icmpEchoRequest
+	binary.BigEndian.PutUint16(packet[4:], p.id)
+	binary.BigEndian.PutUint16(packet[6:], p.seq)
+	binary.BigEndian.PutUint16(packet[2:], checksum(packet))
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+	started := time.Now()
+	if _, err := p.conn.WriteTo(packet, &net.IPAddr{IP: p.ip}); err != nil {
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return 0, errTooLarge
+		}
+		return 0, err
+	}
+	var buffer = make([]byte, maxMTU+headersSize)
+	for {
+		n, from, err := p.conn.ReadFrom(buffer)
+		if err != nil {
+			return 0, err
+		}
+		reply := buffer[:n]
+		if n < 8 || reply[0] != icmpEchoReply || !from.(*net.IPAddr).IP.Equal(p.ip) {
+			continue
+		}
+		if binary.BigEndian.Uint16(reply[4:]) != p.id || binary.BigEndian.Uint16(reply[6:]) != p.seq {
+			continue
+		}
+		return time.Since(started), nil
+	}
+}
+
+func checksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
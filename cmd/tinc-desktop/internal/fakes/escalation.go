@@ -0,0 +1,31 @@
+package fakes
+
+import (
+	"os"
+	"os/exec"
+	"sync/atomic"
+)
+
+const EscalationName = "fake"
+
+// Privilege escalation which runs commands as current user and counts them. Register it by sudo.Register
+// and use EscalationName as helper
+type Escalation struct {
+	Env   []string // extra environment of escalated commands
+	count int32
+}
+
+func (e *Escalation) Name() string    { return EscalationName }
+func (e *Escalation) Available() bool { return true }
+
+func (e *Escalation) Command(args []string) *exec.Cmd {
+	atomic.AddInt32(&e.count, 1)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), e.Env...)
+	return cmd
+}
+
+// Number of escalated commands
+func (e *Escalation) Count() int {
+	return int(atomic.LoadInt32(&e.count))
+}
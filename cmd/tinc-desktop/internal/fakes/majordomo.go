@@ -0,0 +1,62 @@
+package fakes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/reddec/jsonrpc2"
+	"github.com/tinc-boot/tinc-desktop/api/tincwebmajordomo"
+	"github.com/tinc-boot/tincd/network"
+	"net/http/httptest"
+	"sync"
+)
+
+// Local majordomo server which shares single network with every joining node
+type Majordomo struct {
+	Network string
+	Subnet  string
+	Nodes   []*network.Node // host files returned to joined nodes
+	server  *httptest.Server
+	lock    sync.Mutex
+	joined  []*network.Node
+}
+
+// Start majordomo server of network on loopback interface
+func NewMajordomo(name string, subnet string, nodes ...*network.Node) (*Majordomo, error) {
+	md := &Majordomo{Network: name, Subnet: subnet, Nodes: nodes}
+	var router jsonrpc2.Router
+	if err := router.RegisterPositionalOnly("TincWebMajordomo.Join", md.join); err != nil {
+		return nil, err
+	}
+	md.server = httptest.NewServer(jsonrpc2.HandlerRest(&router))
+	return md, nil
+}
+
+// Base URL of server
+func (md *Majordomo) URL() string { return md.server.URL }
+
+// Join link of network (last part of path is JWT-like token with network and subnet)
+func (md *Majordomo) JoinURL() string {
+	payload, _ := json.Marshal(map[string]string{"network": md.Network, "subnet": md.Subnet})
+	return md.server.URL + "/header." + base64.RawStdEncoding.EncodeToString(payload) + ".signature"
+}
+
+// Nodes which joined network
+func (md *Majordomo) Joined() []*network.Node {
+	md.lock.Lock()
+	defer md.lock.Unlock()
+	return append([]*network.Node(nil), md.joined...)
+}
+
+func (md *Majordomo) Close() { md.server.Close() }
+
+func (md *Majordomo) join(ctx context.Context, name string, self *network.Node) (*tincwebmajordomo.Sharing, error) {
+	if name != md.Network {
+		return nil, fmt.Errorf("unknown network %s", name)
+	}
+	md.lock.Lock()
+	md.joined = append(md.joined, self)
+	md.lock.Unlock()
+	return &tincwebmajordomo.Sharing{Name: md.Network, Subnet: md.Subnet, Nodes: md.Nodes}, nil
+}
@@ -0,0 +1,19 @@
+package fakes
+
+import (
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tincd/network"
+	"path/filepath"
+	"time"
+)
+
+const Subnet = "10.155.0.0/16"
+
+// Logging options of workers in tests (zero value is not valid)
+var Logging = logging.Options{Level: "debug", Format: "logfmt", MaxSize: 1, MaxAge: time.Hour, MaxFiles: 1}
+
+// Create configured network in configuration directory
+func CreateNetwork(configDir string, name string) (*network.Network, error) {
+	return netlock.Create(filepath.Join(configDir, name), Subnet)
+}
@@ -0,0 +1,98 @@
+// Package fakes contains in-process replacements of tincd, privilege escalation and majordomo server, so
+// application could be tested end to end without root and network
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"sync"
+)
+
+// Starts fake tincd instances instead of real tincd
+type Starter struct {
+	Peers   []string // connected peers of every instance
+	Hang    bool     // instances ignore stop (stuck tincd)
+	Fail    error    // start fails with the error
+	lock    sync.Mutex
+	started []*Tincd
+}
+
+// Use starter for tincd of application. Returned function restores previous starter
+func (st *Starter) Install() func() {
+	previous := internal.StartTincd
+	internal.StartTincd = st.Start
+	return func() { internal.StartTincd = previous }
+}
+
+// Start fake tincd, stopped when context done (compatible with internal.StartTincd)
+func (st *Starter) Start(ctx context.Context, ntw *network.Network) (tincd.Tincd, error) {
+	if st.Fail != nil {
+		return nil, st.Fail
+	}
+	if !ntw.IsDefined() {
+		return nil, fmt.Errorf("network %s is not defined", ntw.Name())
+	}
+	instance := &Tincd{definition: ntw, peers: st.Peers, hang: st.Hang, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			instance.Stop()
+		case <-instance.done:
+		}
+	}()
+	st.lock.Lock()
+	st.started = append(st.started, instance)
+	st.lock.Unlock()
+	return instance, nil
+}
+
+// Instances started by the starter
+func (st *Starter) Started() []*Tincd {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	return append([]*Tincd(nil), st.started...)
+}
+
+// Fake tincd: does nothing and reports configured peers till stop
+type Tincd struct {
+	definition *network.Network
+	peers      []string
+	hang       bool
+	events     network.Events
+	done       chan struct{}
+	stop       sync.Once
+}
+
+func (t *Tincd) Events() *network.Events      { return &t.events }
+func (t *Tincd) Error() error                 { return nil }
+func (t *Tincd) Done() <-chan struct{}        { return t.done }
+func (t *Tincd) Definition() *network.Network { return t.definition }
+func (t *Tincd) Peers() []string              { return append([]string(nil), t.peers...) }
+
+func (t *Tincd) Stop() {
+	if t.hang {
+		return
+	}
+	t.stop.Do(func() { close(t.done) })
+}
+
+func (t *Tincd) IsRunning() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (t *Tincd) IsActive(node string) bool {
+	for _, peer := range t.peers {
+		if peer == node {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package helperapi
+
+import (
+	"context"
+	client "github.com/reddec/jsonrpc2/client"
+	internal "github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"sync/atomic"
+)
+
+func Default() *HelperClient {
+	return &HelperClient{BaseURL: "http://127.0.0.1:9999"}
+}
+
+type HelperClient struct {
+	BaseURL  string
+	sequence uint64
+}
+
+// Start worker of network (if not yet) and return its API endpoint
+func (impl *HelperClient) Start(ctx context.Context, network string) (reply *internal.Endpoint, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Helper.Start", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Wait for exit of worker and return reason of failure (empty if stopped normally)
+func (impl *HelperClient) Wait(ctx context.Context, network string) (reply string, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Helper.Wait", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Kill worker process of network which doesn't respond
+func (impl *HelperClient) Terminate(ctx context.Context, network string) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Helper.Terminate", atomic.AddUint64(&impl.sequence, 1), &reply, network)
+	return
+}
+
+// Stop all workers and exit
+func (impl *HelperClient) Shutdown(ctx context.Context) (reply bool, err error) {
+	err = client.CallHTTP(ctx, impl.BaseURL, "Helper.Shutdown", atomic.AddUint64(&impl.sequence, 1), &reply)
+	return
+}
@@ -0,0 +1,76 @@
+// Code generated by jsonrpc2. DO NOT EDIT.
+//go:generate jsonrpc2-gen --url http://127.0.0.1:9999 --go-linked --go helperapi/client.go -o helperapi/server.go --package helperapi --go-package helperapi -i interface.go -I Helper
+package helperapi
+
+import (
+	"context"
+	"encoding/json"
+	jsonrpc2 "github.com/reddec/jsonrpc2"
+	internal "github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+)
+
+func RegisterHelper(router *jsonrpc2.Router, wrap internal.Helper) []string {
+	router.RegisterFunc("Helper.Start", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Start(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("Helper.Wait", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Wait(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("Helper.Terminate", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct {
+			Arg0 string `json:"network"`
+		}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params, &args.Arg0)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Terminate(ctx, args.Arg0)
+	})
+
+	router.RegisterFunc("Helper.Shutdown", func(ctx context.Context, params json.RawMessage, positional bool) (interface{}, error) {
+		var args struct{}
+		var err error
+		if positional {
+			err = jsonrpc2.UnmarshalArray(params)
+		} else {
+			err = json.Unmarshal(params, &args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return wrap.Shutdown(ctx)
+	})
+
+	return []string{"Helper.Start", "Helper.Wait", "Helper.Terminate", "Helper.Shutdown"}
+}
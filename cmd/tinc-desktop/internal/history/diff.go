@@ -0,0 +1,91 @@
+package history
+
+import (
+	"sort"
+	"strings"
+)
+
+// Line-based difference between two snapshots. Old could be nil (all files are new).
+// Lines prefixed by "+ " added, by "- " removed; each changed file starts from "=== <name>"
+func Diff(old, current *Snapshot) string {
+	var oldFiles, newFiles map[string]string
+	if old != nil {
+		oldFiles = old.Files
+	}
+	if current != nil {
+		newFiles = current.Files
+	}
+
+	var names []string
+	for name := range oldFiles {
+		names = append(names, name)
+	}
+	for name := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		before, after := oldFiles[name], newFiles[name]
+		if before == after {
+			continue
+		}
+		out.WriteString("=== " + name + "\n")
+		for _, line := range diffLines(splitLines(before), splitLines(after)) {
+			out.WriteString(line + "\n")
+		}
+	}
+	return out.String()
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// classic LCS-based diff; host files are small enough for O(n*m)
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ans []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ans = append(ans, "- "+a[i])
+			i++
+		default:
+			ans = append(ans, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ans = append(ans, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		ans = append(ans, "+ "+b[j])
+	}
+	return ans
+}
@@ -0,0 +1,306 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLimit = 32
+	dirName      = ".history"
+	configFile   = "tinc.conf"
+	hostsDir     = "hosts"
+)
+
+// Private key is not saved in snapshots, so network could not be restored to state with other keys
+var ErrKeyChanged = errors.New("snapshot was made with other keys of node: revert keys rotation instead")
+
+var (
+	globalLock sync.Mutex
+	locks      = make(map[string]*sync.Mutex)
+)
+
+// Saved state of network configuration (tinc.conf) and hosts directory. Private key is not included.
+type Snapshot struct {
+	ID      string            `json:"id"`
+	Created time.Time         `json:"created"`
+	Reason  string            `json:"reason"`
+	Files   map[string]string `json:"files"` // relative (slash separated) path -> content
+}
+
+// Versioned snapshots of single network stored in the network directory
+type History struct {
+	Network *network.Network
+	Limit   int // maximum number of snapshots to keep, 0 means DefaultLimit
+	lock    *sync.Mutex
+}
+
+// History of network. Changes are serialized with all other histories of the same network directory
+func Of(ntw *network.Network) *History {
+	root, err := filepath.Abs(ntw.Root)
+	if err != nil {
+		root = filepath.Clean(ntw.Root)
+	}
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	lock, ok := locks[root]
+	if !ok {
+		lock = &sync.Mutex{}
+		locks[root] = lock
+	}
+	return &History{Network: ntw, lock: lock}
+}
+
+// Track change of network: saves current state if it differs from the last snapshot, applies change and saves
+// resulted state with provided reason
+func (h *History) Track(reason string, change func() error) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err := h.saveIfChanged("external change"); err != nil {
+		return fmt.Errorf("save current state: %w", err)
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	return h.saveIfChanged(reason)
+}
+
+// Save current state as snapshot (if it differs from the last one)
+func (h *History) Save(reason string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.saveIfChanged(reason)
+}
+
+// List of snapshots. Newest first
+func (h *History) List() ([]*Snapshot, error) {
+	list, err := ioutil.ReadDir(h.dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ans []*Snapshot
+	for _, item := range list {
+		if item.IsDir() || filepath.Ext(item.Name()) != ".json" {
+			continue
+		}
+		snap, err := h.read(item.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot %s: %w", item.Name(), err)
+		}
+		ans = append(ans, snap)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].ID > ans[j].ID
+	})
+	return ans, nil
+}
+
+// Restore network files from snapshot. Current state saved before restore
+func (h *History) Rollback(id string) error {
+	snap, err := h.Get(id)
+	if err != nil {
+		return err
+	}
+	return h.Track("rollback to "+snap.Created.Format(time.RFC3339), func() error {
+		return h.restore(snap)
+	})
+}
+
+// Get snapshot by ID
+func (h *History) Get(id string) (*Snapshot, error) {
+	return h.read(id + ".json")
+}
+
+// Previous (older) snapshot or nil
+func (h *History) Previous(id string) (*Snapshot, error) {
+	list, err := h.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, snap := range list {
+		if snap.ID == id && i+1 < len(list) {
+			return list[i+1], nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *History) saveIfChanged(reason string) error {
+	current, err := h.capture()
+	if err != nil {
+		return err
+	}
+	list, err := h.List()
+	if err != nil {
+		return err
+	}
+	if len(list) > 0 && sameFiles(list[0].Files, current) {
+		return nil
+	}
+	now := time.Now()
+	snap := &Snapshot{
+		ID:      strconv.FormatInt(now.UnixNano(), 10),
+		Created: now,
+		Reason:  reason,
+		Files:   current,
+	}
+	if err := h.write(snap); err != nil {
+		return err
+	}
+	return h.prune(append([]*Snapshot{snap}, list...))
+}
+
+func (h *History) capture() (map[string]string, error) {
+	var files = make(map[string]string)
+	data, err := ioutil.ReadFile(filepath.Join(h.Network.Root, configFile))
+	if err != nil {
+		return nil, err
+	}
+	files[configFile] = string(data)
+
+	nodes, err := h.Network.Nodes()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, node := range nodes {
+		data, err := ioutil.ReadFile(h.Network.NodeFile(node))
+		if err != nil {
+			return nil, err
+		}
+		files[hostsDir+"/"+node] = string(data)
+	}
+	return files, nil
+}
+
+func (h *History) restore(snap *Snapshot) error {
+	current, err := h.capture()
+	if err != nil {
+		return err
+	}
+	self, err := h.Network.Self()
+	if err != nil {
+		return err
+	}
+	if err := h.checkKey(self, snap); err != nil {
+		return err
+	}
+	for name := range current {
+		if _, ok := snap.Files[name]; !ok {
+			if err := os.Remove(filepath.Join(h.Network.Root, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(h.Network.Root, hostsDir), 0755); err != nil {
+		return err
+	}
+	for name, content := range snap.Files {
+		file := filepath.Join(h.Network.Root, filepath.FromSlash(name))
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			return err
+		}
+		if err := network.ApplyOwnerOfSudoUser(file); err != nil {
+			return err
+		}
+	}
+	return h.bumpVersion(self.Version)
+}
+
+// peers accept only newer versions of host file, so restored self node should get version above the replaced one
+func (h *History) bumpVersion(replaced int) error {
+	self, err := h.Network.Self()
+	if err != nil {
+		return err
+	}
+	if self.Version > replaced {
+		return nil
+	}
+	self.Version = replaced + 1
+	data, err := self.Build()
+	if err != nil {
+		return err
+	}
+	file := h.Network.NodeFile(self.Name)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
+
+// public key of self node in snapshot should match current private key
+func (h *History) checkKey(self *network.Node, snap *Snapshot) error {
+	var cfg network.Config
+	if err := cfg.Parse([]byte(snap.Files[configFile])); err != nil {
+		return fmt.Errorf("parse snapshot config: %w", err)
+	}
+	var node network.Node
+	if err := node.Parse([]byte(snap.Files[hostsDir+"/"+cfg.Name])); err != nil {
+		return fmt.Errorf("parse snapshot self node: %w", err)
+	}
+	if strings.TrimSpace(node.PublicKey) != strings.TrimSpace(self.PublicKey) {
+		return ErrKeyChanged
+	}
+	return nil
+}
+
+func (h *History) prune(list []*Snapshot) error {
+	limit := h.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	for i := limit; i < len(list); i++ {
+		if err := os.Remove(filepath.Join(h.dir(), list[i].ID+".json")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *History) write(snap *Snapshot) error {
+	if err := os.MkdirAll(h.dir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(h.dir(), snap.ID+".json"), data, 0644)
+}
+
+func (h *History) read(fileName string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(filepath.Join(h.dir(), fileName))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	return &snap, json.Unmarshal(data, &snap)
+}
+
+func (h *History) dir() string {
+	return filepath.Join(h.Network.Root, dirName)
+}
+
+func sameFiles(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if ov, ok := b[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
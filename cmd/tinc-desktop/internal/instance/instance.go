@@ -0,0 +1,87 @@
+// Package instance keeps single running instance of application per configuration directory. Next instances
+// ask running one to show its window
+package instance
+
+import (
+	"errors"
+	"github.com/theckman/go-flock"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	lockName    = "app.lock"
+	addressName = "app.address"
+	dialTimeout = 3 * time.Second
+)
+
+var ErrRunning = errors.New("application is already running")
+
+type Guard struct {
+	lock     *flock.Flock
+	listener net.Listener
+	file     string
+}
+
+// Acquire guard of configuration directory. If another instance is running, it is asked to show window
+// and ErrRunning is returned
+func Acquire(configDir string) (*Guard, error) {
+	lock := flock.New(filepath.Join(configDir, lockName))
+	ok, err := lock.TryLock()
+	if err != nil {
+		return nil, err
+	}
+	file := filepath.Join(configDir, addressName)
+	if !ok {
+		if err := activate(file); err != nil {
+			return nil, err
+		}
+		return nil, ErrRunning
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = lock.Unlock()
+		return nil, err
+	}
+	if err := ioutil.WriteFile(file, []byte(listener.Addr().String()), 0600); err != nil {
+		_ = listener.Close()
+		_ = lock.Unlock()
+		return nil, err
+	}
+	return &Guard{lock: lock, listener: listener, file: file}, nil
+}
+
+// Call show for every request of another instance until guard is closed
+func (g *Guard) Serve(show func()) {
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+		show()
+	}
+}
+
+func (g *Guard) Close() error {
+	_ = g.listener.Close()
+	_ = os.Remove(g.file)
+	return g.lock.Unlock()
+}
+
+// ask running instance to show window
+func activate(file string) error {
+	address, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", strings.TrimSpace(string(address)), dialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
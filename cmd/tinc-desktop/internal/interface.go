@@ -1,6 +1,10 @@
 package internal
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 /*
 
@@ -24,13 +28,111 @@ So we have to control by TCP, however we can detect death by exit
    Peers +<----->|
          |       |
    Kill  +------>|
+         |       |
+  Reload +------>|
+         |       |
+Diagnose +<----->|
+         |       |
+    Logs +<------+
+         |       |
+   Debug +------>|
+         |       |
+    Stop +------>|
          |
 
+```
+
+To ask credentials only once, workers are spawned by single privileged helper (started on first use):
+
+```
+
+ Desktop application         helper (root)          workers
+         |                        |                    |
+   Start +----------------------->+------ spawn ------>|
+         |<------- endpoint ------+                    |
+         |                        |                    |
+  Worker +<------------------------------------------->|
+         |                        |                    |
+    Wait +<-----------------------+<------ exit -------+
+         |                        |
+
 ```
 */
 type Worker interface {
 	Kill(ctx context.Context) (bool, error)
+	// Stop tincd gracefully (by signal, so tinc-down is executed) and kill it if it is not stopped in timeout.
+	// Returns step which stopped tincd
+	Stop(ctx context.Context, timeout time.Duration) (StopStep, error)
 	Peers(ctx context.Context) ([]string, error)
+	// Reload configuration and host files without restart
+	Reload(ctx context.Context) (bool, error)
+	// Check reachability of peer by name: latency, loss and path MTU
+	Diagnose(ctx context.Context, node string) (*Diagnostics, error)
+	// Worker and tincd log lines after provided ID. Waits for new lines if there are no such lines yet
+	Logs(ctx context.Context, after uint64) ([]LogLine, error)
+	// Change tincd debug level without restart
+	SetDebugLevel(ctx context.Context, level int) (bool, error)
+}
+
+// API of worker with auth token
+type Endpoint struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// Privileged process which runs workers of many networks, so credentials are asked once per session.
+// Workers are started as for root application and clients talk to them directly by returned endpoints
+type Helper interface {
+	// Start worker of network (if not yet) and return its API endpoint
+	Start(ctx context.Context, network string) (*Endpoint, error)
+	// Wait for exit of worker and return reason of failure (empty if stopped normally)
+	Wait(ctx context.Context, network string) (string, error)
+	// Kill worker process of network which doesn't respond
+	Terminate(ctx context.Context, network string) (bool, error)
+	// Stop all workers and exit
+	Shutdown(ctx context.Context) (bool, error)
+}
+
+// Step of stop which finished network
+type StopStep string
+
+const (
+	StepGraceful   StopStep = "graceful"   // tincd stopped by signal in time
+	StepForced     StopStep = "forced"     // tincd killed after timeout
+	StepTerminated StopStep = "terminated" // worker process killed because it didn't exit
+)
+
+// Log line of worker or tincd (in worker log format)
+type LogLine struct {
+	ID   uint64 `json:"id"`
+	Text string `json:"text"`
+}
+
+// Result of peer reachability check
+type Diagnostics struct {
+	Node     string        `json:"node"`
+	IP       string        `json:"ip"`
+	Sent     int           `json:"sent"`
+	Received int           `json:"received"`
+	Loss     float64       `json:"loss"` // percents
+	MinRTT   time.Duration `json:"minRtt"`
+	AvgRTT   time.Duration `json:"avgRtt"`
+	MaxRTT   time.Duration `json:"maxRtt"`
+	MTU      int           `json:"mtu,omitempty"`      // path MTU (IP packet size), 0 if unknown
+	MTUError string        `json:"mtuError,omitempty"` // reason why MTU is unknown
+}
+
+func (d *Diagnostics) String() string {
+	text := fmt.Sprintf("%s (%s): %d/%d received, %.0f%% loss", d.Node, d.IP, d.Received, d.Sent, d.Loss)
+	if d.Received > 0 {
+		text += fmt.Sprintf("\nrtt min/avg/max %v/%v/%v", d.MinRTT, d.AvgRTT, d.MaxRTT)
+	}
+	if d.MTU > 0 {
+		text += fmt.Sprintf("\npath MTU %d", d.MTU)
+	} else if d.MTUError != "" {
+		text += "\npath MTU unknown: " + d.MTUError
+	}
+	return text
 }
 
 type Port interface {
@@ -41,5 +143,6 @@ type Port interface {
 }
 
 type Spawner interface {
-	Spawn(network string, done chan struct{}) (Port, error)
+	// Start worker. Context limits only start (including privilege escalation), not lifetime of worker
+	Spawn(ctx context.Context, network string, done chan struct{}) (Port, error)
 }
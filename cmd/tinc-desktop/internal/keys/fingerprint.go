@@ -0,0 +1,68 @@
+package keys
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+// number of leading fingerprint bytes in words representation
+const wordsCount = 6
+
+// SHA-256 of DER-encoded public key
+type Fingerprint [sha256.Size]byte
+
+// Fingerprint of PEM-encoded public key (as in host file)
+func FingerprintOf(publicKey string) (Fingerprint, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(publicKey)))
+	if block == nil {
+		return Fingerprint{}, errors.New("invalid public key")
+	}
+	return sha256.Sum256(block.Bytes), nil
+}
+
+// Hex representation (pairs separated by colon)
+func (fp Fingerprint) String() string {
+	var parts = make([]string, len(fp))
+	for i, b := range fp {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, ":")
+}
+
+// Short human-readable representation to compare by voice
+func (fp Fingerprint) Words() string {
+	var parts = make([]string, wordsCount)
+	for i := range parts {
+		parts[i] = wordList[fp[i]]
+	}
+	return strings.Join(parts, " ")
+}
+
+var wordList = [256]string{
+	"acid", "acorn", "acre", "actor", "adapt", "admit", "adobe", "agent", "alarm", "album", "alert", "alien",
+	"alpha", "amber", "anchor", "angle", "apple", "april", "apron", "arena", "argue", "armor", "arrow", "atlas",
+	"atom", "audio", "avoid", "award", "bacon", "badge", "bagel", "baker", "bamboo", "banjo", "barn", "basil",
+	"basin", "beach", "beard", "berry", "bison", "blade", "blank", "blast", "blend", "bloom", "board", "bonus",
+	"boost", "brain", "brick", "bride", "brook", "brush", "bugle", "cabin", "cable", "cactus", "camel", "canal",
+	"candy", "canoe", "cargo", "carpet", "cedar", "chalk", "chef", "chess", "chief", "cider", "civic", "clamp",
+	"cliff", "clock", "cloud", "coast", "cobra", "cocoa", "comet", "coral", "cotton", "crane", "crisp", "daisy",
+	"dance", "delta", "denim", "depot", "diary", "diner", "disco", "dodge", "dolphin", "donut", "dragon",
+	"drama", "dream", "drift", "drum", "dune", "eagle", "easel", "echo", "eclipse", "elbow", "elder", "ember",
+	"emerald", "engine", "epoch", "fable", "falcon", "fancy", "fern", "ferry", "fiber", "field", "flame",
+	"flint", "flute", "focus", "forest", "fossil", "frost", "fudge", "galaxy", "garden", "garlic", "gecko",
+	"giant", "ginger", "glacier", "globe", "glove", "goat", "grape", "gravel", "habit", "hammer", "harbor",
+	"harp", "hazel", "helmet", "heron", "hockey", "honey", "hornet", "hotel", "humble", "husky", "igloo",
+	"image", "index", "indigo", "inlet", "iris", "island", "ivory", "jacket", "jaguar", "jelly", "jewel",
+	"jockey", "judge", "juice", "jungle", "kayak", "kernel", "kettle", "kiosk", "kitten", "koala", "label",
+	"ladder", "lagoon", "lemon", "lentil", "level", "lilac", "linen", "lizard", "llama", "lobster", "locket",
+	"lotus", "lunar", "magnet", "mango", "maple", "marble", "meadow", "melon", "metal", "mirror", "mocha",
+	"molar", "motor", "mural", "nectar", "needle", "nickel", "noble", "nomad", "nugget", "oasis", "ocean",
+	"olive", "omega", "onion", "opera", "orbit", "orchid", "otter", "oyster", "paddle", "panda", "paper",
+	"parrot", "pastel", "peach", "pebble", "pepper", "piano", "pilot", "pixel", "plaza", "plum", "polar",
+	"poppy", "prism", "pulse", "puzzle", "quartz", "quill", "rabbit", "radar", "radio", "raven", "razor",
+	"relay", "ribbon", "ridge", "robin", "rocket", "rodeo", "ruby", "saddle", "salmon", "sandal", "satin",
+	"scarf", "shadow", "shell", "silver", "sketch", "slope",
+}
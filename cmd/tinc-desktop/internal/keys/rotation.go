@@ -0,0 +1,173 @@
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// tincd (1.0 protocol) uses only RSA keys. Ed25519 keys (ed25519_key.priv) exist only in tinc 1.1, which is not
+// supported by tincd library, so they are not generated
+const (
+	KeySize        = 4096
+	privateKeyFile = "rsa_key.priv"
+	rotationDir    = ".rotation"
+	oldHostFile    = "host"
+)
+
+var ErrRotationPending = errors.New("previous key rotation is not confirmed yet")
+
+// Rotate generates new key pair for self node and updates self host file with increased version (so peers will
+// accept it). Previous private key and host file are kept until Confirm or Revert.
+func Rotate(ntw *network.Network) (*network.Node, error) {
+	if Pending(ntw) {
+		return nil, ErrRotationPending
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		return nil, err
+	}
+	if err := backup(ntw, self.Name); err != nil {
+		_ = os.RemoveAll(filepath.Join(ntw.Root, rotationDir))
+		return nil, fmt.Errorf("backup old key: %w", err)
+	}
+	if err := generate(ntw, self); err != nil {
+		// failed rotation should not block next one
+		if restoreErr := restore(ntw, self.Name); restoreErr != nil {
+			return nil, fmt.Errorf("%v (restore old key: %w)", err, restoreErr)
+		}
+		return nil, err
+	}
+	return self, nil
+}
+
+func generate(ntw *network.Network, self *network.Node) error {
+	private, err := rsa.GenerateKey(rand.Reader, KeySize)
+	if err != nil {
+		return err
+	}
+	err = writeFile(filepath.Join(ntw.Root, privateKeyFile), pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(private),
+	}), 0600)
+	if err != nil {
+		return fmt.Errorf("save private key: %w", err)
+	}
+	self.PublicKey = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&private.PublicKey),
+	}))
+	self.Version++
+	return writeNode(ntw, self)
+}
+
+// Pending checks that there is unconfirmed rotation
+func Pending(ntw *network.Network) bool {
+	_, err := os.Stat(filepath.Join(ntw.Root, rotationDir, privateKeyFile))
+	return err == nil
+}
+
+// Confirm rotation: removes old keys
+func Confirm(ntw *network.Network) error {
+	if !Pending(ntw) {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(ntw.Root, rotationDir))
+}
+
+// Revert rotation: restores old keys. Version of self node is still increased to override rotated host file on peers
+func Revert(ntw *network.Network) error {
+	if !Pending(ntw) {
+		return errors.New("no pending rotation")
+	}
+	current, err := ntw.Self()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(ntw.Root, rotationDir)
+	data, err := ioutil.ReadFile(filepath.Join(dir, oldHostFile))
+	if err != nil {
+		return err
+	}
+	var old network.Node
+	if err := old.Parse(data); err != nil {
+		return err
+	}
+	privateKey, err := ioutil.ReadFile(filepath.Join(dir, privateKeyFile))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(ntw.Root, privateKeyFile), privateKey, 0600); err != nil {
+		return err
+	}
+	current.PublicKey = old.PublicKey
+	current.Version++
+	if err := writeNode(ntw, current); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func backup(ntw *network.Network, selfName string) error {
+	dir := filepath.Join(ntw.Root, rotationDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := network.ApplyOwnerOfSudoUser(dir); err != nil {
+		return err
+	}
+	hostData, err := ioutil.ReadFile(ntw.NodeFile(selfName))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, oldHostFile), hostData, 0644); err != nil {
+		return err
+	}
+	privateKey, err := ioutil.ReadFile(filepath.Join(ntw.Root, privateKeyFile))
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, privateKeyFile), privateKey, 0600)
+}
+
+// put backed up files back as is and remove backup
+func restore(ntw *network.Network, selfName string) error {
+	dir := filepath.Join(ntw.Root, rotationDir)
+	hostData, err := ioutil.ReadFile(filepath.Join(dir, oldHostFile))
+	if err != nil {
+		return err
+	}
+	privateKey, err := ioutil.ReadFile(filepath.Join(dir, privateKeyFile))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(ntw.Root, privateKeyFile), privateKey, 0600); err != nil {
+		return err
+	}
+	if err := writeFile(ntw.NodeFile(selfName), hostData, 0644); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func writeNode(ntw *network.Network, node *network.Node) error {
+	data, err := node.Build()
+	if err != nil {
+		return err
+	}
+	return writeFile(ntw.NodeFile(node.Name), data, 0644)
+}
+
+func writeFile(file string, data []byte, perm os.FileMode) error {
+	if err := ioutil.WriteFile(file, data, perm); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
@@ -0,0 +1,109 @@
+package keys
+
+import (
+	"encoding/json"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const verifiedFile = ".verified.json"
+
+type State int
+
+const (
+	Unverified State = iota
+	Verified
+	Changed // key was verified, but now it is different
+)
+
+func (s State) String() string {
+	switch s {
+	case Verified:
+		return "verified"
+	case Changed:
+		return "key changed"
+	default:
+		return "not verified"
+	}
+}
+
+// Peers fingerprints verified by user out-of-band
+type VerifiedPeers struct {
+	file  string
+	Nodes map[string]string `json:"nodes"` // node name -> hex fingerprint
+}
+
+func LoadVerified(ntw *network.Network) (*VerifiedPeers, error) {
+	vp := &VerifiedPeers{file: filepath.Join(ntw.Root, verifiedFile), Nodes: make(map[string]string)}
+	data, err := ioutil.ReadFile(vp.file)
+	if os.IsNotExist(err) {
+		return vp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, vp); err != nil {
+		return nil, err
+	}
+	if vp.Nodes == nil {
+		vp.Nodes = make(map[string]string)
+	}
+	return vp, nil
+}
+
+func (vp *VerifiedPeers) Mark(node string, fp Fingerprint) {
+	vp.Nodes[node] = fp.String()
+}
+
+func (vp *VerifiedPeers) Unmark(node string) {
+	delete(vp.Nodes, node)
+}
+
+func (vp *VerifiedPeers) State(node string, fp Fingerprint) State {
+	saved, ok := vp.Nodes[node]
+	if !ok {
+		return Unverified
+	}
+	if saved != fp.String() {
+		return Changed
+	}
+	return Verified
+}
+
+// State of node by host file definition
+func (vp *VerifiedPeers) StateOf(node *network.Node) State {
+	fp, err := FingerprintOf(node.PublicKey)
+	if err != nil {
+		if _, ok := vp.Nodes[node.Name]; ok {
+			return Changed
+		}
+		return Unverified
+	}
+	return vp.State(node.Name, fp)
+}
+
+// Names of verified nodes which have another key in provided definitions
+func (vp *VerifiedPeers) Changed(nodes []*network.Node) []string {
+	var ans []string
+	for _, node := range nodes {
+		if vp.StateOf(node) == Changed {
+			ans = append(ans, node.Name)
+		}
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func (vp *VerifiedPeers) Save() error {
+	data, err := json.MarshalIndent(vp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(vp.file, data, 0644); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(vp.file)
+}
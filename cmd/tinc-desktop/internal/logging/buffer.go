@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultBufferSize = 1000
+
+// Numbered log line
+type Line struct {
+	ID   uint64
+	Text string
+}
+
+// In-memory ring of last log lines with ability to wait for new lines. Used to ship worker output to application
+type Buffer struct {
+	Size    int // max number of lines, 0 - DefaultBufferSize
+	lock    sync.Mutex
+	lines   []Line
+	last    uint64
+	read    uint64 // last line ID requested by reader
+	changed chan struct{}
+}
+
+func (b *Buffer) Write(data []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\r\n"), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			b.Add(line)
+		}
+	}
+	return len(data), nil
+}
+
+func (b *Buffer) Add(text string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	size := b.Size
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	b.last++
+	b.lines = append(b.lines, Line{ID: b.last, Text: text})
+	if len(b.lines) > size {
+		b.lines = b.lines[len(b.lines)-size:]
+	}
+	b.notify()
+}
+
+// Lines after provided ID. If there are no such lines, waits for new lines until context done
+func (b *Buffer) Since(ctx context.Context, after uint64) []Line {
+	for {
+		b.lock.Lock()
+		if after > b.read {
+			b.read = after
+			b.notify()
+		}
+		var ans []Line
+		for _, line := range b.lines {
+			if line.ID > after {
+				ans = append(ans, line)
+			}
+		}
+		changed := b.wait()
+		b.lock.Unlock()
+		if len(ans) > 0 {
+			return ans
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// Wait till reader requests all lines or timeout. Should be called before exit to deliver last lines
+func (b *Buffer) Flush(timeout time.Duration) {
+	deadline := time.After(timeout)
+	for {
+		b.lock.Lock()
+		flushed := b.read >= b.last
+		changed := b.wait()
+		b.lock.Unlock()
+		if flushed {
+			return
+		}
+		select {
+		case <-deadline:
+			return
+		case <-changed:
+		}
+	}
+}
+
+func (b *Buffer) wait() <-chan struct{} {
+	if b.changed == nil {
+		b.changed = make(chan struct{})
+	}
+	return b.changed
+}
+
+func (b *Buffer) notify() {
+	if b.changed != nil {
+		close(b.changed)
+		b.changed = nil
+	}
+}
+
+// Messages of last warning and error records (up to limit), oldest first. Used to explain failures
+func (b *Buffer) Problems(limit int) []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var ans []string
+	for i := len(b.lines) - 1; i >= 0 && len(ans) < limit; i-- {
+		rec := ParseRecord(b.lines[i].Text)
+		if rec.Level < LevelWarn {
+			continue
+		}
+		text := rec.Message
+		if reason := rec.Fields["error"]; reason != "" {
+			text += ": " + reason
+		}
+		if len(ans) > 0 && ans[0] == text {
+			continue
+		}
+		ans = append([]string{text}, ans...)
+	}
+	return ans
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const followInterval = 500 * time.Millisecond
+
+// Follow file (like tail -F) from the beginning and call handler for each complete line until context done.
+// Truncated or re-created file is read again from the beginning. Rest of file is read once after context done.
+func Follow(ctx context.Context, file string, handler func(line string)) {
+	var offset int64
+	var partial string
+	for {
+		offset, partial = readFrom(file, offset, partial, handler)
+		select {
+		case <-ctx.Done():
+			// last lines are usually the most important (reason of exit)
+			readFrom(file, offset, partial, handler)
+			return
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+func readFrom(file string, offset int64, partial string, handler func(line string)) (int64, string) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, ""
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return offset, partial
+	}
+	if info.Size() < offset {
+		// truncated
+		offset = 0
+		partial = ""
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, partial
+	}
+	reader := bufio.NewReader(f)
+	for {
+		chunk, err := reader.ReadString('\n')
+		offset += int64(len(chunk))
+		if err != nil {
+			return offset, partial + chunk
+		}
+		handler(strings.TrimRight(partial+chunk, "\r\n"))
+		partial = ""
+	}
+}
+
+// Location of tincd output written by tincd library (truncated on each start)
+func TincdFile(networkRoot string) string {
+	return filepath.Join(networkRoot, "log.txt")
+}
+
+// Forward tincd output to the first logger which accepts level of line. tincd has no levels in output,
+// so only problems are logged above debug level
+func FollowTincd(ctx context.Context, networkRoot string, loggers ...*Logger) {
+	Follow(ctx, TincdFile(networkRoot), func(line string) {
+		level := tincdLevel(line)
+		for _, logger := range loggers {
+			if logger.Enabled(level) {
+				logger.Log(level, line)
+				return
+			}
+		}
+	})
+}
+
+func tincdLevel(line string) Level {
+	lower := strings.ToLower(line)
+	for _, marker := range []string{"error", "failed", "cannot", "could not", "unable"} {
+		if strings.Contains(lower, marker) {
+			return LevelWarn
+		}
+	}
+	return LevelDebug
+}
@@ -0,0 +1,214 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+func ParseLevel(text string) (Level, error) {
+	switch strings.ToLower(text) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %s", text)
+	}
+}
+
+type Format string
+
+const (
+	FormatLogfmt Format = "logfmt"
+	FormatJSON   Format = "json"
+)
+
+// Structured leveled logger. Safe for concurrent use
+type Logger struct {
+	out    *output
+	fields []interface{}
+}
+
+type output struct {
+	lock   sync.Mutex
+	writer io.Writer
+	format Format
+	level  Level
+}
+
+func New(writer io.Writer, format Format, level Level) *Logger {
+	return &Logger{out: &output{writer: writer, format: format, level: level}}
+}
+
+// Child logger with additional key-value pairs in each record
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{out: l.out, fields: fields}
+}
+
+// Logger with the same format and fields but another output and level
+func (l *Logger) Fork(writer io.Writer, level Level) *Logger {
+	l.out.lock.Lock()
+	format := l.out.format
+	l.out.lock.Unlock()
+	return &Logger{out: &output{writer: writer, format: format, level: level}, fields: l.fields}
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.Log(LevelDebug, msg, kv...) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.Log(LevelInfo, msg, kv...) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.Log(LevelWarn, msg, kv...) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.Log(LevelError, msg, kv...) }
+
+func (l *Logger) Enabled(level Level) bool {
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+	return level >= l.out.level
+}
+
+func (l *Logger) SetLevel(level Level) {
+	l.out.lock.Lock()
+	l.out.level = level
+	l.out.lock.Unlock()
+}
+
+func (l *Logger) Log(level Level, msg string, kv ...interface{}) {
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+	if level < l.out.level {
+		return
+	}
+	var pairs = make([]interface{}, 0, 6+len(l.fields)+len(kv))
+	pairs = append(pairs, "time", time.Now().Format(time.RFC3339Nano), "level", level.String(), "msg", msg)
+	pairs = append(pairs, l.fields...)
+	pairs = append(pairs, kv...)
+	var line []byte
+	if l.out.format == FormatJSON {
+		line = encodeJSON(pairs)
+	} else {
+		line = encodeLogfmt(pairs)
+	}
+	_, _ = l.out.writer.Write(line)
+}
+
+// Writer which logs each written line as separate record with provided level. Used to redirect standard logger
+// and output of sub-processes
+func (l *Logger) Writer(level Level) io.Writer {
+	return &lineWriter{logger: l, level: level}
+}
+
+type lineWriter struct {
+	logger *Logger
+	level  Level
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (lw *lineWriter) Write(data []byte) (int, error) {
+	lw.lock.Lock()
+	defer lw.lock.Unlock()
+	lw.buffer.Write(data)
+	for {
+		idx := bytes.IndexByte(lw.buffer.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(lw.buffer.Next(idx + 1)))
+		if line != "" {
+			lw.logger.Log(lw.level, line)
+		}
+	}
+	return len(data), nil
+}
+
+func encodeJSON(pairs []interface{}) []byte {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		key, _ := json.Marshal(fmt.Sprint(pairs[i]))
+		out.Write(key)
+		out.WriteByte(':')
+		value, err := json.Marshal(jsonValue(valueAt(pairs, i+1)))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(valueAt(pairs, i+1)))
+		}
+		out.Write(value)
+	}
+	out.WriteString("}\n")
+	return out.Bytes()
+}
+
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func encodeLogfmt(pairs []interface{}) []byte {
+	var out bytes.Buffer
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			out.WriteByte(' ')
+		}
+		out.WriteString(fmt.Sprint(pairs[i]))
+		out.WriteByte('=')
+		out.WriteString(quoteLogfmt(fmt.Sprint(valueAt(pairs, i+1))))
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+func quoteLogfmt(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func valueAt(pairs []interface{}, idx int) interface{} {
+	if idx < len(pairs) {
+		return pairs[idx]
+	}
+	return nil
+}
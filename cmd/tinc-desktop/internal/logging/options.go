@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	logsDir     = "logs"
+	networksDir = "networks"
+	appFile     = "app.log"
+)
+
+// Logging configuration (command line flags)
+type Options struct {
+	Level    string        `long:"level" env:"LEVEL" description:"Log level" default:"info" choice:"debug" choice:"info" choice:"warn" choice:"error"`
+	Format   string        `long:"format" env:"FORMAT" description:"Log format" default:"logfmt" choice:"logfmt" choice:"json"`
+	MaxSize  int64         `long:"max-size" env:"MAX_SIZE" description:"Max size of log file in megabytes before rotation" default:"10"`
+	MaxAge   time.Duration `long:"max-age" env:"MAX_AGE" description:"Max age of previous log files" default:"168h"`
+	MaxFiles int           `long:"max-files" env:"MAX_FILES" description:"Max number of previous log files" default:"10"`
+}
+
+// Application log location
+func AppFile(configDir string) string {
+	return filepath.Join(configDir, logsDir, appFile)
+}
+
+// Network (worker and tincd) log location
+func NetworkFile(configDir string, network string) string {
+	return filepath.Join(configDir, logsDir, networksDir, network+".log")
+}
+
+// Open rotating file and create logger which writes to it and to extra writers (stderr for example)
+func (opts Options) Open(file string, extra ...io.Writer) (*Logger, io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	rotating := &RotatingFile{
+		Path:     file,
+		MaxSize:  opts.MaxSize * 1024 * 1024,
+		MaxAge:   opts.MaxAge,
+		MaxFiles: opts.MaxFiles,
+	}
+	if err := rotating.Open(); err != nil {
+		return nil, nil, fmt.Errorf("open log file: %w", err)
+	}
+	format := Format(opts.Format)
+	if format == "" {
+		format = FormatLogfmt
+	}
+	var out io.Writer = rotating
+	if len(extra) > 0 {
+		out = io.MultiWriter(append([]io.Writer{rotating}, extra...)...)
+	}
+	return New(out, format, level), rotating, nil
+}
+
+// Command line arguments to pass the same options to sub-process
+func (opts Options) Args() []string {
+	return []string{
+		"--log-level", opts.Level,
+		"--log-format", opts.Format,
+		"--log-max-size", strconv.FormatInt(opts.MaxSize, 10),
+		"--log-max-age", opts.MaxAge.String(),
+		"--log-max-files", strconv.Itoa(opts.MaxFiles),
+	}
+}
+
+func applyOwner(file string) error {
+	if err := network.ApplyOwnerOfSudoUser(filepath.Dir(file)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
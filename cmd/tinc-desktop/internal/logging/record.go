@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parsed log line (logfmt or JSON). Unknown lines are kept as message with info level
+type Record struct {
+	Time    string
+	Level   Level
+	Message string
+	Network string
+	Fields  map[string]string // all parsed key-value pairs
+	Raw     string
+}
+
+func ParseRecord(line string) Record {
+	var fields map[string]string
+	if strings.HasPrefix(line, "{") {
+		fields = parseJSON(line)
+	} else {
+		fields = parseLogfmt(line)
+	}
+	rec := Record{Raw: line, Level: LevelInfo, Message: line}
+	if fields == nil {
+		return rec
+	}
+	if msg, ok := fields["msg"]; ok {
+		rec.Message = msg
+	}
+	if lvl, err := ParseLevel(fields["level"]); err == nil {
+		rec.Level = lvl
+	}
+	rec.Time = fields["time"]
+	rec.Network = fields["network"]
+	rec.Fields = fields
+	return rec
+}
+
+// Key-value pairs except time, level and message in logfmt, sorted by key
+func (rec Record) Extra() string {
+	var keys []string
+	for k := range rec.Fields {
+		switch k {
+		case "time", "level", "msg":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, k+"="+quoteLogfmt(rec.Fields[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseJSON(line string) map[string]string {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil
+	}
+	var ans = make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			ans[k] = s
+		} else {
+			data, _ := json.Marshal(v)
+			ans[k] = string(data)
+		}
+	}
+	return ans
+}
+
+// minimal logfmt parser: key=value or key="quoted value"
+func parseLogfmt(line string) map[string]string {
+	var ans = make(map[string]string)
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], " \"") {
+			return nil
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		var value string
+		if strings.HasPrefix(line, "\"") {
+			end := closingQuote(line)
+			if end < 0 {
+				return nil
+			}
+			unquoted, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil
+			}
+			value = unquoted
+			line = line[end+1:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		ans[key] = value
+	}
+	if _, ok := ans["msg"]; !ok {
+		return nil
+	}
+	return ans
+}
+
+// index of closing (not escaped) quote for string started by quote
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// File which is rotated when exceeds size limit and on each open (to keep previous sessions separately).
+// Old files are removed by age and count.
+type RotatingFile struct {
+	Path     string
+	MaxSize  int64         // bytes, 0 - unlimited
+	MaxAge   time.Duration // 0 - unlimited
+	MaxFiles int           // number of previous files to keep, 0 - unlimited
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+}
+
+// Open file for new session: previous content (if exists) moved to backup
+func (rf *RotatingFile) Open() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(rf.Path), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(rf.Path); err == nil && info.Size() > 0 {
+		if err := rf.backup(); err != nil {
+			return err
+		}
+	}
+	return rf.open()
+}
+
+func (rf *RotatingFile) Write(data []byte) (int, error) {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+	if rf.MaxSize > 0 && rf.size+int64(len(data)) > rf.MaxSize && rf.size > 0 {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(data)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// Previous files (backups) sorted from newest to oldest
+func (rf *RotatingFile) Backups() ([]string, error) {
+	dir := filepath.Dir(rf.Path)
+	prefix, ext := rf.parts()
+	list, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ans []string
+	for _, item := range list {
+		name := item.Name()
+		if item.IsDir() || !strings.HasPrefix(name, prefix+".") || !strings.HasSuffix(name, ext) || name == filepath.Base(rf.Path) {
+			continue
+		}
+		ans = append(ans, filepath.Join(dir, name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ans)))
+	return ans, nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+	if err := rf.backup(); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	return applyOwner(rf.Path)
+}
+
+func (rf *RotatingFile) backup() error {
+	prefix, ext := rf.parts()
+	name := filepath.Join(filepath.Dir(rf.Path), prefix+"."+time.Now().Format(backupTimeFormat)+ext)
+	if err := os.Rename(rf.Path, name); err != nil {
+		return err
+	}
+	return rf.cleanup()
+}
+
+func (rf *RotatingFile) cleanup() error {
+	backups, err := rf.Backups()
+	if err != nil {
+		return err
+	}
+	for i, name := range backups {
+		expired := false
+		if rf.MaxFiles > 0 && i >= rf.MaxFiles {
+			expired = true
+		}
+		if info, err := os.Stat(name); err == nil && rf.MaxAge > 0 && time.Since(info.ModTime()) > rf.MaxAge {
+			expired = true
+		}
+		if expired {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// base name without extension and extension of log file
+func (rf *RotatingFile) parts() (string, string) {
+	base := filepath.Base(rf.Path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext), ext
+}
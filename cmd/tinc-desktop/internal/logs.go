@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"strings"
+	"time"
+)
+
+// Max time for waiting new lines in one Logs call
+const LogsWait = 10 * time.Second
+
+// Implementation of Worker.Logs by buffer
+func ReadLogs(ctx context.Context, buffer *logging.Buffer, after uint64) []LogLine {
+	ctx, cancel := context.WithTimeout(ctx, LogsWait)
+	defer cancel()
+	var ans = make([]LogLine, 0)
+	for _, line := range buffer.Since(ctx, after) {
+		ans = append(ans, LogLine{ID: line.ID, Text: line.Text})
+	}
+	return ans
+}
+
+// Add last problems from output to error (usually exit code) to show real reason of failure
+func WithOutput(err error, output *logging.Buffer) error {
+	if err == nil {
+		return nil
+	}
+	problems := output.Problems(3)
+	if len(problems) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, strings.Join(problems, "; "))
+}
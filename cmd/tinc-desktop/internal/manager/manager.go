@@ -1,15 +1,129 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
 	"log"
 	"sync"
+	"time"
 )
 
+const (
+	startCheckInterval  = 500 * time.Millisecond
+	healthCheckInterval = 10 * time.Second
+	checkTimeout        = 3 * time.Second
+	startTimeout        = 30 * time.Second // API of worker should respond in time after spawn
+	exitTimeout         = 5 * time.Second  // worker should exit in time after tincd stop or termination
+	DefaultStopTimeout  = 10 * time.Second
+)
+
+// Spawner which could find worker of network left running by previous instance of application.
+// Nil port is returned if there is no such worker
+type Adopter interface {
+	Adopt(ctx context.Context, network string, done chan struct{}) (internal.Port, error)
+}
+
+// Port which process could be killed if it doesn't respond or doesn't exit
+type Terminator interface {
+	Terminate() error
+}
+
+// Transition of network state. Error is set for transitions to Failed
+type Event struct {
+	Network  string
+	State    State
+	Previous State
+	Error    error
+}
+
+// Workers of networks. Operations on different networks are independent: slow start of one network (for example,
+// waiting for password) doesn't block others. Zero value is ready to use after Spawner is set
 type Manager struct {
-	Spawner internal.Spawner
-	workers map[string]internal.Port
-	lock    sync.Mutex
+	Spawner     internal.Spawner
+	StopTimeout time.Duration // time for tincd to stop gracefully before kill (DefaultStopTimeout if not set)
+	workers     map[string]internal.Port
+	states      map[string]State
+	errors      map[string]error
+	subscribers map[chan Event]struct{}
+	networks    map[string]chan struct{} // per-network locks
+	lock        sync.Mutex
+}
+
+// Subscribe to workers events. Events are dropped if subscriber is not reading. Returned function unsubscribes
+func (mgr *Manager) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+	mgr.lock.Lock()
+	if mgr.subscribers == nil {
+		mgr.subscribers = make(map[chan Event]struct{})
+	}
+	mgr.subscribers[ch] = struct{}{}
+	mgr.lock.Unlock()
+	return ch, func() {
+		mgr.lock.Lock()
+		delete(mgr.subscribers, ch)
+		mgr.lock.Unlock()
+	}
+}
+
+// Error of last failed worker of network (cleared on next start)
+func (mgr *Manager) LastError(name string) error {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+	return mgr.errors[name]
+}
+
+// Current state of network and error of last failure
+func (mgr *Manager) State(name string) (State, error) {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+	return mgr.state(name), mgr.errors[name]
+}
+
+// should be called under lock
+func (mgr *Manager) state(name string) State {
+	if state, ok := mgr.states[name]; ok {
+		return state
+	}
+	return Stopped
+}
+
+// Change state of network if current state is one of expected (any if not set) and transition is allowed.
+// Subscribers are notified about changes
+func (mgr *Manager) transit(name string, next State, err error, expected ...State) bool {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+	current := mgr.state(name)
+	if len(expected) > 0 && !oneOf(current, expected) {
+		return false
+	}
+	if !current.can(next) {
+		return false
+	}
+	if mgr.states == nil {
+		mgr.states = make(map[string]State)
+	}
+	mgr.states[name] = next
+	if next == Failed {
+		if mgr.errors == nil {
+			mgr.errors = make(map[string]error)
+		}
+		mgr.errors[name] = err
+	} else if next == Authorizing || next == Starting {
+		delete(mgr.errors, name)
+	}
+	mgr.notify(Event{Network: name, State: next, Previous: current, Error: err})
+	return true
+}
+
+// should be called under lock
+func (mgr *Manager) notify(event Event) {
+	for ch := range mgr.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
 }
 
 func (mgr *Manager) Find(name string) internal.Port {
@@ -28,37 +142,286 @@ func (mgr *Manager) Names() []string {
 	return ans
 }
 
-func (mgr *Manager) SpawnSudoContext(name string) (internal.Port, error) {
-	mgr.lock.Lock()
-	defer mgr.lock.Unlock()
+// Start worker of network if it is not running. Only start of the same network is waited, so the call could be
+// cancelled by context even during escalation of privileges
+func (mgr *Manager) Spawn(ctx context.Context, name string) (internal.Port, error) {
+	unlock, err := mgr.lockNetwork(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-	if wp, ok := mgr.workers[name]; ok {
+	if wp := mgr.Find(name); wp != nil && !isDone(wp) {
 		return wp, nil
 	}
 
-	if mgr.workers == nil {
-		mgr.workers = make(map[string]internal.Port)
+	if auth, ok := mgr.Spawner.(Authorizer); ok && auth.NeedsAuthorization() {
+		mgr.transit(name, Authorizing, nil)
 	}
-
 	done := make(chan struct{})
-
-	wp, err := mgr.Spawner.Spawn(name, done)
+	wp, err := mgr.Spawner.Spawn(ctx, name, done)
 	if err != nil {
 		close(done)
+		if ctx.Err() != nil {
+			mgr.transit(name, Stopped, nil)
+		} else {
+			mgr.transit(name, Failed, err)
+		}
 		return nil, err
 	}
 
+	mgr.attach(name, wp)
+	return wp, nil
+}
+
+// Find workers of networks left running by previous instance of application (after crash) and manage them as
+// spawned. Hung workers are killed. Should be called before start of networks
+func (mgr *Manager) Adopt(ctx context.Context, names []string) {
+	adopter, ok := mgr.Spawner.(Adopter)
+	if !ok {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if err := mgr.adopt(ctx, adopter, name); err != nil {
+				log.Println(name, "adopt:", err)
+			}
+		}(name)
+	}
+	wg.Wait()
+}
+
+func (mgr *Manager) adopt(ctx context.Context, adopter Adopter, name string) error {
+	unlock, err := mgr.lockNetwork(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if mgr.Find(name) != nil {
+		return nil
+	}
+	wp, err := adopter.Adopt(ctx, name, make(chan struct{}))
+	if err != nil || wp == nil {
+		return err
+	}
+	log.Println(name, "running worker adopted")
+	mgr.attach(name, wp)
+	return nil
+}
+
+// should be called under network lock
+func (mgr *Manager) attach(name string, wp internal.Port) {
+	mgr.lock.Lock()
+	if mgr.workers == nil {
+		mgr.workers = make(map[string]internal.Port)
+	}
 	mgr.workers[name] = wp
+	mgr.lock.Unlock()
+	mgr.transit(name, Starting, nil)
+
+	go mgr.wait(name, wp)
+	go mgr.monitor(name, wp)
+}
+
+// Stop worker of network and wait for exit. tincd is asked to exit gracefully, then it is killed after
+// stop timeout and then worker process is terminated if it is not exited. Returns step which stopped network
+// (empty if network is not running or worker exited without reply). Not running network is not an error
+func (mgr *Manager) Stop(ctx context.Context, name string) (internal.StopStep, error) {
+	unlock, err := mgr.lockNetwork(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	return mgr.stop(ctx, name)
+}
+
+// Kill worker process of network without stopping tincd gracefully
+func (mgr *Manager) Terminate(name string) error {
+	wp := mgr.Find(name)
+	if wp == nil {
+		return nil
+	}
+	return terminate(wp)
+}
 
-	go func() {
-		<-done
-		if err := wp.Error(); err != nil {
-			log.Println(name, err)
+// Stop all workers concurrently and wait for exit. Returns first error (context error if some workers
+// are not stopped in time)
+func (mgr *Manager) StopAll(ctx context.Context) error {
+	names := mgr.Names()
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		go func(name string) {
+			log.Println("stopping", name)
+			_, err := mgr.Stop(ctx, name)
+			errs <- err
+		}(name)
+	}
+	var first error
+	for range names {
+		if err := <-errs; err != nil && first == nil {
+			first = err
 		}
-		mgr.lock.Lock()
+	}
+	return first
+}
+
+// Restart running worker: stop it, wait for exit and spawn again
+func (mgr *Manager) Restart(ctx context.Context, name string) (internal.Port, error) {
+	if _, err := mgr.Stop(ctx, name); err != nil {
+		return nil, err
+	}
+	return mgr.Spawn(ctx, name)
+}
+
+// should be called under network lock
+func (mgr *Manager) stop(ctx context.Context, name string) (internal.StopStep, error) {
+	wp := mgr.Find(name)
+	if wp == nil {
+		return "", nil
+	}
+	mgr.transit(name, Stopping, nil, Starting, Running, Degraded)
+	timeout := mgr.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, timeout+exitTimeout)
+	step, err := wp.API().Stop(stopCtx, timeout)
+	cancel()
+	if err != nil && !isDone(wp) {
+		log.Println(name, "stop:", err)
+	}
+	// not responding worker is terminated without waiting
+	if (err == nil || isDone(wp)) && waitDone(ctx, wp, exitTimeout) {
+		log.Println(name, "stopped:", step)
+		mgr.release(name, wp)
+		return step, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return step, err
+	}
+	log.Println(name, "worker is not exited, terminating")
+	if err := terminate(wp); err != nil {
+		return step, err
+	}
+	if !waitDone(ctx, wp, exitTimeout) {
+		if err := ctx.Err(); err != nil {
+			return internal.StepTerminated, err
+		}
+		return internal.StepTerminated, errors.New("worker is not exited after termination")
+	}
+	log.Println(name, "stopped:", internal.StepTerminated)
+	mgr.release(name, wp)
+	return internal.StepTerminated, nil
+}
+
+func (mgr *Manager) wait(name string, wp internal.Port) {
+	<-wp.Done()
+	if err := wp.Error(); err != nil {
+		log.Println(name, err)
+	}
+	mgr.release(name, wp)
+}
+
+// Unregister exited worker and change state of network (once per worker). Stop releases worker itself,
+// so next spawn under the same network lock starts from settled state
+func (mgr *Manager) release(name string, wp internal.Port) {
+	err := wp.Error()
+	mgr.lock.Lock()
+	current := mgr.workers[name] == wp
+	// killed tincd is not a failure if stop was requested
+	requested := mgr.state(name) == Stopping
+	if current {
 		delete(mgr.workers, name)
-		mgr.lock.Unlock()
-	}()
+	}
+	mgr.lock.Unlock()
+	if !current {
+		return
+	}
+	if err != nil && !requested {
+		mgr.transit(name, Failed, err)
+	} else {
+		mgr.transit(name, Stopped, nil)
+	}
+}
 
-	return wp, nil
+// Check worker API till exit: Running if it responds, Degraded if not (after start timeout)
+func (mgr *Manager) monitor(name string, wp internal.Port) {
+	started := time.Now()
+	interval := startCheckInterval
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+		_, err := wp.API().Peers(ctx)
+		cancel()
+		if err == nil {
+			mgr.transit(name, Running, nil, Starting, Degraded)
+			interval = healthCheckInterval
+		} else if time.Since(started) > startTimeout {
+			mgr.transit(name, Degraded, nil, Starting, Running)
+		}
+		select {
+		case <-wp.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func oneOf(state State, list []State) bool {
+	for _, item := range list {
+		if item == state {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire lock of network or fail when context done
+func (mgr *Manager) lockNetwork(ctx context.Context, name string) (func(), error) {
+	mgr.lock.Lock()
+	if mgr.networks == nil {
+		mgr.networks = make(map[string]chan struct{})
+	}
+	ch, ok := mgr.networks[name]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		mgr.networks[name] = ch
+	}
+	mgr.lock.Unlock()
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func terminate(wp internal.Port) error {
+	t, ok := wp.(Terminator)
+	if !ok {
+		return errors.New("worker could not be terminated")
+	}
+	return t.Terminate()
+}
+
+// wait for exit of worker in timeout, false if it is not exited or context done
+func waitDone(ctx context.Context, wp internal.Port, timeout time.Duration) bool {
+	select {
+	case <-wp.Done():
+		return true
+	case <-ctx.Done():
+	case <-time.After(timeout):
+	}
+	return false
+}
+
+func isDone(wp internal.Port) bool {
+	select {
+	case <-wp.Done():
+		return true
+	default:
+		return false
+	}
 }
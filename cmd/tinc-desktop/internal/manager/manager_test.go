@@ -0,0 +1,187 @@
+package manager_test
+
+import (
+	"context"
+	"errors"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/fakes"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/spawners"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const waitTimeout = 10 * time.Second
+
+func setup(t *testing.T, starter *fakes.Starter, networks ...string) (*manager.Manager, string, func()) {
+	dir, err := ioutil.TempDir("", "tinc-desktop-manager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range networks {
+		if _, err := fakes.CreateNetwork(dir, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restore := starter.Install()
+	mgr := &manager.Manager{Spawner: &spawners.SameProcess{ConfigLocation: dir, Logging: fakes.Logging}}
+	return mgr, dir, func() {
+		_ = mgr.StopAll(context.Background())
+		restore()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func waitState(t *testing.T, mgr *manager.Manager, name string, expected manager.State) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		state, err := mgr.State(name)
+		if state == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("network %s is %s (error: %v), expected %s", name, state, err, expected)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestManager_SpawnAndStop(t *testing.T) {
+	starter := &fakes.Starter{Peers: []string{"alice", "bob"}}
+	mgr, _, cleanup := setup(t, starter, "net1")
+	defer cleanup()
+	events, unsubscribe := mgr.Subscribe()
+	defer unsubscribe()
+
+	port, err := mgr.Spawn(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, mgr, "net1", manager.Running)
+	peers, err := port.API().Peers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(peers, starter.Peers) {
+		t.Errorf("peers %v, expected %v", peers, starter.Peers)
+	}
+	if again, err := mgr.Spawn(context.Background(), "net1"); err != nil || again != port {
+		t.Errorf("running worker should be returned on second spawn: %v", err)
+	}
+
+	// fake tincd has no PID file, so it could be only killed
+	step, err := mgr.Stop(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != internal.StepForced {
+		t.Errorf("stop step %s, expected %s", step, internal.StepForced)
+	}
+	waitState(t, mgr, "net1", manager.Stopped)
+	if mgr.Find("net1") != nil {
+		t.Error("stopped worker is still registered")
+	}
+
+	var transitions []manager.State
+	for len(events) > 0 {
+		transitions = append(transitions, (<-events).State)
+	}
+	expected := []manager.State{manager.Starting, manager.Running, manager.Stopping, manager.Stopped}
+	if !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("transitions %v, expected %v", transitions, expected)
+	}
+}
+
+func TestManager_SpawnFailure(t *testing.T) {
+	starter := &fakes.Starter{Fail: errors.New("no tun device")}
+	mgr, _, cleanup := setup(t, starter, "net1")
+	defer cleanup()
+
+	if _, err := mgr.Spawn(context.Background(), "net1"); err == nil {
+		t.Fatal("spawn should fail")
+	}
+	state, err := mgr.State("net1")
+	if state != manager.Failed || err == nil {
+		t.Errorf("state %s with error %v, expected failure", state, err)
+	}
+}
+
+func TestManager_LockedNetwork(t *testing.T) {
+	mgr, dir, cleanup := setup(t, &fakes.Starter{}, "net1")
+	defer cleanup()
+	if _, err := mgr.Spawn(context.Background(), "net1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// another instance of application
+	lock, err := netlock.Acquire(filepath.Join(dir, "net1"))
+	if !errors.Is(err, netlock.ErrLocked) {
+		t.Errorf("running network should be locked, got %v", err)
+	}
+	if lock != nil {
+		_ = lock.Release()
+	}
+
+	other := &manager.Manager{Spawner: &spawners.SameProcess{ConfigLocation: dir, Logging: fakes.Logging}}
+	if _, err := other.Spawn(context.Background(), "net1"); !errors.Is(err, netlock.ErrLocked) {
+		t.Errorf("spawn of locked network should fail, got %v", err)
+	}
+}
+
+func TestManager_StopAll(t *testing.T) {
+	starter := &fakes.Starter{}
+	mgr, _, cleanup := setup(t, starter, "net1", "net2", "net3")
+	defer cleanup()
+	for _, name := range []string{"net1", "net2", "net3"} {
+		if _, err := mgr.Spawn(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mgr.StopAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if names := mgr.Names(); len(names) != 0 {
+		t.Errorf("workers %v are not stopped", names)
+	}
+	for _, instance := range starter.Started() {
+		if instance.IsRunning() {
+			t.Errorf("tincd of %s is running", instance.Definition().Name())
+		}
+	}
+}
+
+func TestManager_Restart(t *testing.T) {
+	starter := &fakes.Starter{}
+	mgr, _, cleanup := setup(t, starter, "net1")
+	defer cleanup()
+	first, err := mgr.Spawn(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := mgr.Restart(context.Background(), "net1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("worker is not restarted")
+	}
+	if started := len(starter.Started()); started != 2 {
+		t.Errorf("tincd started %d times, expected 2", started)
+	}
+	waitState(t, mgr, "net1", manager.Running)
+}
+
+func TestManager_StopNotRunning(t *testing.T) {
+	mgr, _, cleanup := setup(t, &fakes.Starter{}, "net1")
+	defer cleanup()
+	step, err := mgr.Stop(context.Background(), "net1")
+	if err != nil || step != "" {
+		t.Errorf("stop of not running network: %s, %v", step, err)
+	}
+}
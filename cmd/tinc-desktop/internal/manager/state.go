@@ -0,0 +1,44 @@
+package manager
+
+// State of network in manager
+type State string
+
+const (
+	Stopped     State = "stopped"
+	Authorizing State = "authorizing" // waiting for credentials (privilege escalation)
+	Starting    State = "starting"    // worker spawned, API is not responding yet
+	Running     State = "running"
+	Degraded    State = "degraded" // worker is running, but API is not responding
+	Stopping    State = "stopping"
+	Failed      State = "failed" // start failed or worker exited with error
+)
+
+// allowed transitions
+var transitions = map[State][]State{
+	Stopped:     {Authorizing, Starting, Failed}, // spawner could fail without authorization
+	Failed:      {Authorizing, Starting, Failed}, // repeated failure updates error
+	Authorizing: {Starting, Stopped, Failed},
+	Starting:    {Running, Degraded, Stopping, Stopped, Failed},
+	Running:     {Degraded, Stopping, Stopped, Failed},
+	Degraded:    {Running, Stopping, Stopped, Failed},
+	Stopping:    {Stopped, Failed},
+}
+
+// Worker process exists in the state
+func (s State) Active() bool {
+	return s == Starting || s == Running || s == Degraded || s == Stopping
+}
+
+func (s State) can(next State) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Spawner which asks credentials before start. Start is reported as Authorizing state
+type Authorizer interface {
+	NeedsAuthorization() bool
+}
@@ -0,0 +1,57 @@
+// Package netlock prevents concurrent use of the same network by several instances of application by file lock
+// in network directory. Lock is released by OS when process exits
+package netlock
+
+import (
+	"errors"
+	"github.com/theckman/go-flock"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"os"
+	"path/filepath"
+)
+
+const fileName = ".lock"
+
+var ErrLocked = errors.New("network is used by another process")
+
+type Lock struct {
+	flock *flock.Flock
+}
+
+// Acquire lock of network without waiting. ErrLocked is returned if network is used by another process
+func Acquire(directory string) (*Lock, error) {
+	file := filepath.Join(directory, fileName)
+	// lock file created by privileged process should be readable by others (lock requires read access only)
+	if f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0644); err != nil {
+		return nil, err
+	} else {
+		_ = f.Close()
+	}
+	fl := flock.New(file)
+	ok, err := fl.TryLock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLocked
+	}
+	return &Lock{flock: fl}, nil
+}
+
+func (l *Lock) Release() error {
+	return l.flock.Unlock()
+}
+
+// Create and configure network under its lock, so network used by another process is not re-configured
+func Create(location string, subnet string) (*network.Network, error) {
+	if err := os.MkdirAll(location, 0755); err != nil {
+		return nil, err
+	}
+	lock, err := Acquire(location)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+	return tincd.Create(location, subnet)
+}
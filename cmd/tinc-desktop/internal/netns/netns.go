@@ -0,0 +1,10 @@
+package netns
+
+import "errors"
+
+var ErrUnsupported = errors.New("network namespaces are supported only on Linux")
+
+// Name of namespace for network. Applications are routed through the network by `ip netns exec <name> <app>`
+func Name(network string) string {
+	return "tinc-" + network
+}
@@ -0,0 +1,115 @@
+package netns
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	namespacesDir = "/var/run/netns"
+	waitInterval  = 200 * time.Millisecond
+)
+
+// `ip` command used by tinc-up and tinc-down in namespace: interface is moved to namespace before any change,
+// so it is never configured in current namespace
+const interceptor = `#!/bin/sh
+if [ -n "$INTERFACE" ]; then
+	%[1]s link set dev "$INTERFACE" netns %[2]s 2>/dev/null
+fi
+exec %[1]s -n %[2]s "$@"
+`
+
+var ipBinary = "ip" // resolved by Intercept before PATH is changed
+
+// Create namespace if it doesn't exist and bring loopback up. Requires root
+func Ensure(name string) error {
+	if _, err := os.Stat(filepath.Join(namespacesDir, name)); os.IsNotExist(err) {
+		if err := ip("netns", "add", name); err != nil {
+			return err
+		}
+	}
+	return ip("-n", name, "link", "set", "lo", "up")
+}
+
+// Remove namespace. Applications still running in namespace keep it until exit
+func Remove(name string) error {
+	return ip("netns", "delete", name)
+}
+
+// Intercept `ip` command of tincd scripts (found in PATH) by one which moves VPN interface to namespace and
+// configures it there. tincd keeps working with the device and connects to peers from current namespace.
+// Returned function restores PATH. Requires root
+func Intercept(name string) (func(), error) {
+	bin, err := exec.LookPath(ipBinary)
+	if err != nil {
+		return nil, err
+	}
+	ipBinary = bin
+	dir, err := ioutil.TempDir("", "tinc-netns")
+	if err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf(interceptor, quote(bin), quote(name))
+	if err := ioutil.WriteFile(filepath.Join(dir, "ip"), []byte(script), 0755); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	path := os.Getenv("PATH")
+	if err := os.Setenv("PATH", dir+string(os.PathListSeparator)+path); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return func() {
+		_ = os.Setenv("PATH", path)
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+// Wait till interface is configured in namespace by tinc-up. Interface configured in current namespace means
+// that tinc-up doesn't use intercepted `ip` command and traffic leaks outside of namespace
+func WaitInterface(ctx context.Context, name, iface string) error {
+	for {
+		if configured(iface) {
+			return fmt.Errorf("interface %s is configured outside of namespace %s", iface, name)
+		}
+		output, err := exec.Command(ipBinary, "-n", name, "-o", "-4", "addr", "show", "dev", iface).Output()
+		if err == nil && len(bytes.TrimSpace(output)) > 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+func configured(iface string) bool {
+	info, err := net.InterfaceByName(iface)
+	if err != nil {
+		return false
+	}
+	addrs, err := info.Addrs()
+	return err == nil && len(addrs) > 0
+}
+
+func ip(args ...string) error {
+	output, err := exec.Command(ipBinary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ip %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// shell single quotes
+func quote(text string) string {
+	return "'" + strings.Replace(text, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package netns
+
+import "context"
+
+func Ensure(name string) error { return ErrUnsupported }
+
+func Remove(name string) error { return ErrUnsupported }
+
+func Intercept(name string) (func(), error) { return nil, ErrUnsupported }
+
+func WaitInterface(ctx context.Context, name, iface string) error { return ErrUnsupported }
@@ -0,0 +1,18 @@
+package internal
+
+import "github.com/tinc-boot/tincd/network"
+
+// Defined networks in config directory. Service directories (logs for example) are skipped
+func Networks(configDir string) ([]*network.Network, error) {
+	list, err := network.List(configDir)
+	if err != nil {
+		return nil, err
+	}
+	var ans []*network.Network
+	for _, ntw := range list {
+		if ntw.IsDefined() {
+			ans = append(ans, ntw)
+		}
+	}
+	return ans, nil
+}
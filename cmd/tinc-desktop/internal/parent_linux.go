@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Parent PID of process from /proc/<pid>/stat. Name of process could contain spaces and braces, so fields are
+// counted from the last brace
+func parentPid(pid int) (int, error) {
+	data, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	text := string(data)
+	fields := strings.Fields(text[strings.LastIndex(text, ")")+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected stat of process %d", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,17 @@
+// +build !linux,!windows
+
+package internal
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func parentPid(pid int) (int, error) {
+	output, err := exec.Command("ps", "-o", "ppid=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,157 @@
+package privileges
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	capNetBindService = 10
+	capNetAdmin       = 12
+	capNetRaw         = 13
+	// marks relaunched process to prevent loops
+	relaunchedEnv = "TINC_DESKTOP_UNPRIVILEGED"
+)
+
+// Capabilities required by network worker: tun device and interface configuration (tincd and tinc-up scripts),
+// raw ICMP socket for diagnostics and binding of port below 1024 if needed
+func Capabilities(privilegedPort bool) []uintptr {
+	if privilegedPort {
+		return []uintptr{capNetAdmin, capNetRaw, capNetBindService}
+	}
+	return []uintptr{capNetAdmin, capNetRaw}
+}
+
+// Current process has CAP_NET_ADMIN in effective set: started by root or has file capabilities
+// (setcap cap_net_admin+ep)
+func HasNetAdmin() bool {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		return err == nil && caps&(1<<capNetAdmin) != 0
+	}
+	return false
+}
+
+// Run current executable with the same arguments as invoking user (sudo, pkexec or doas) with only provided
+// capabilities (ambient, so they are inherited by tincd and scripts) and wait for it. Signals are forwarded.
+// Prepare is called with target uid and gid before start to hand over files created by previous privileged runs.
+// Returns false if privileges should not be dropped: process is relaunched already, doesn't have CAP_NET_ADMIN
+// or it is root without invoking user (system service).
+func Relaunch(caps []uintptr, prepare func(uid, gid int) error) (bool, error) {
+	if os.Getenv(relaunchedEnv) != "" || !HasNetAdmin() {
+		return false, nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return true, err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), relaunchedEnv+"=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		AmbientCaps: caps,
+		Pdeathsig:   syscall.SIGTERM,
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		owner, ok, err := invokingUser()
+		if err != nil {
+			return true, err
+		} else if !ok {
+			return false, nil
+		}
+		credential, err := credentialOf(owner)
+		if err != nil {
+			return true, err
+		}
+		cmd.SysProcAttr.Credential = credential
+		cmd.Env = append(cmd.Env, "HOME="+owner.HomeDir, "USER="+owner.Username)
+		uid, gid = int(credential.Uid), int(credential.Gid)
+	}
+	if err := prepare(uid, gid); err != nil {
+		return true, err
+	}
+	if err := cmd.Start(); err != nil {
+		return true, fmt.Errorf("start unprivileged worker: %w", err)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+	return true, cmd.Wait()
+}
+
+// Ownership of written files is fixed (by tincd library as well) only for user of sudo, so user of pkexec
+// or doas is exported the same way
+func ExportInvokingUser() error {
+	if os.Getuid() != 0 || os.Getenv("SUDO_USER") != "" {
+		return nil
+	}
+	u, ok, err := invokingUser()
+	if !ok {
+		return err
+	}
+	return os.Setenv("SUDO_USER", u.Username)
+}
+
+// user who escalated privileges of the process
+func invokingUser() (*user.User, bool, error) {
+	if uid := os.Getenv("SUDO_UID"); uid != "" {
+		u, err := user.LookupId(uid)
+		return u, err == nil, err
+	}
+	if uid := os.Getenv("PKEXEC_UID"); uid != "" {
+		u, err := user.LookupId(uid)
+		return u, err == nil, err
+	}
+	if name := os.Getenv("DOAS_USER"); name != "" {
+		u, err := user.Lookup(name)
+		return u, err == nil, err
+	}
+	return nil, false, nil
+}
+
+func credentialOf(u *user.User) (*syscall.Credential, error) {
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	groups, err := u.GroupIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if id, err := strconv.ParseUint(group, 10, 32); err == nil {
+			credential.Groups = append(credential.Groups, uint32(id))
+		}
+	}
+	return credential, nil
+}
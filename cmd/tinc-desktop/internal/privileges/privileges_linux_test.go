@@ -0,0 +1,40 @@
+package privileges
+
+import (
+	"os"
+	"os/user"
+	"testing"
+)
+
+func TestExportInvokingUser(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("requires root")
+	}
+	nobody, err := user.LookupId("65534")
+	if err != nil {
+		t.Skip("no user with UID 65534")
+	}
+	for _, name := range []string{"SUDO_USER", "SUDO_UID", "PKEXEC_UID", "DOAS_USER"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+
+	if err := ExportInvokingUser(); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("SUDO_USER") != "" {
+		t.Fatal("user exported without escalation")
+	}
+
+	os.Setenv("PKEXEC_UID", nobody.Uid)
+	if err := ExportInvokingUser(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("SUDO_USER"); got != nobody.Username {
+		t.Fatalf("expected %s, got %s", nobody.Username, got)
+	}
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package privileges
+
+// Capabilities are supported only on Linux
+func Capabilities(privilegedPort bool) []uintptr { return nil }
+
+func HasNetAdmin() bool { return false }
+
+func ExportInvokingUser() error { return nil }
+
+// Privileges are never dropped outside Linux
+func Relaunch(caps []uintptr, prepare func(uid, gid int) error) (bool, error) { return false, nil }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// What is started by service
+type Mode string
+
+const (
+	ModeWorker Mode = "worker" // tinc-desktop in foreground (run command): logs, debug level and settings are used
+	ModeTincd  Mode = "tincd"  // tincd directly
+)
+
+const (
+	environmentFile   = ".service.env"
+	defaultDebugLevel = 4
+)
+
+var ErrUnsupported = errors.New("services are supported only with systemd")
+
+// Templated (per network) systemd unit
+type Unit struct {
+	Mode       Mode
+	Executable string // tinc-desktop or tincd binary
+	ConfigDir  string
+}
+
+// Name of template unit file
+func (u *Unit) TemplateName() string {
+	if u.Mode == ModeTincd {
+		return "tinc-desktop-tincd@.service"
+	}
+	return "tinc-desktop@.service"
+}
+
+// Name of unit instance for network
+func (u *Unit) InstanceName(network string) string {
+	return strings.Replace(u.TemplateName(), "@", "@"+network, 1)
+}
+
+// Content of template unit file. Network name is instance name (%i)
+func (u *Unit) Template() []byte {
+	var out bytes.Buffer
+	out.WriteString("[Unit]\n")
+	out.WriteString("Description=Tinc network %i (tinc-desktop, " + string(u.modeOrDefault()) + ")\n")
+	out.WriteString("After=network-online.target\n")
+	out.WriteString("Wants=network-online.target\n\n")
+	out.WriteString("[Service]\n")
+	out.WriteString("Type=simple\n")
+	if u.Mode == ModeTincd {
+		// template is shared by networks, so debug level of each network is in own file
+		out.WriteString("Environment=TINCD_DEBUG=" + strconv.Itoa(defaultDebugLevel) + "\n")
+		out.WriteString("EnvironmentFile=-" + escapeSpecifiers(u.ConfigDir) + "/%i/" + environmentFile + "\n")
+	}
+	out.WriteString("ExecStart=" + strings.Join(u.command(), " ") + "\n")
+	out.WriteString("Restart=on-failure\n")
+	out.WriteString("RestartSec=5\n\n")
+	out.WriteString("[Install]\n")
+	out.WriteString("WantedBy=multi-user.target\n")
+	return out.Bytes()
+}
+
+func (u *Unit) command() []string {
+	// paths are escaped before instance specifier is added
+	executable, dir := escapeSpecifiers(u.Executable), escapeSpecifiers(u.ConfigDir)
+	if u.Mode == ModeTincd {
+		return []string{quote(executable), "-D", "--debug=${TINCD_DEBUG}", "--pidfile", quote(dir + "/%i/pid.run"), "-c", quote(dir + "/%i")}
+	}
+	return []string{quote(executable), "-c", quote(dir), "-n", "%i", "run"}
+}
+
+func (u *Unit) modeOrDefault() Mode {
+	if u.Mode == "" {
+		return ModeWorker
+	}
+	return u.Mode
+}
+
+// Save debug level of tincd for service of network (tincd mode)
+func WriteEnvironment(configDir string, name string, debugLevel int) error {
+	file := filepath.Join(configDir, name, environmentFile)
+	if err := ioutil.WriteFile(file, []byte("TINCD_DEBUG="+strconv.Itoa(debugLevel)+"\n"), 0644); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
+
+// Update debug level of tincd for service of network if service environment exists. Applied on restart of service
+func UpdateEnvironment(configDir string, name string, debugLevel int) error {
+	if _, err := os.Stat(filepath.Join(configDir, name, environmentFile)); os.IsNotExist(err) {
+		return nil
+	}
+	return WriteEnvironment(configDir, name, debugLevel)
+}
+
+// systemd quoting: double quotes with escaped backslashes and quotes
+func quote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.Replace(strings.Replace(arg, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// systemd expands specifiers (%i, %h, ...) in unit file, so literal percent is doubled
+func escapeSpecifiers(text string) string {
+	return strings.Replace(text, "%", "%%", -1)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const unitsDir = "/etc/systemd/system"
+
+// Install template unit (if needed), enable and start service for network. Requires root
+func Install(unit *Unit, network string) error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return ErrUnsupported
+	}
+	if err := ioutil.WriteFile(filepath.Join(unitsDir, unit.TemplateName()), unit.Template(), 0644); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", unit.InstanceName(network))
+}
+
+// Stop and disable service of network. Template unit is removed if there are no more enabled instances
+func Uninstall(unit *Unit, network string) error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return ErrUnsupported
+	}
+	if err := systemctl("disable", "--now", unit.InstanceName(network)); err != nil {
+		return err
+	}
+	instances, err := ioutil.ReadDir(filepath.Join(unitsDir, "multi-user.target.wants"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	prefix := strings.TrimSuffix(unit.TemplateName(), ".service")
+	for _, item := range instances {
+		if strings.HasPrefix(item.Name(), prefix) {
+			return nil
+		}
+	}
+	if err := os.Remove(filepath.Join(unitsDir, unit.TemplateName())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+// State of service: active state (active, inactive, failed, ...) and enabled flag. Doesn't require root
+func Status(unit *Unit, network string) (string, bool, error) {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return "", false, ErrUnsupported
+	}
+	name := unit.InstanceName(network)
+	// is-active and is-enabled return non-zero code for inactive/disabled units
+	active, _ := exec.Command("systemctl", "is-active", name).Output()
+	enabled, _ := exec.Command("systemctl", "is-enabled", name).Output()
+	state := strings.TrimSpace(string(active))
+	if state == "" {
+		state = "unknown"
+	}
+	return state, strings.TrimSpace(string(enabled)) == "enabled", nil
+}
+
+func systemctl(args ...string) error {
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+// +build !linux
+
+package service
+
+func Install(unit *Unit, network string) error   { return ErrUnsupported }
+func Uninstall(unit *Unit, network string) error { return ErrUnsupported }
+
+func Status(unit *Unit, network string) (string, bool, error) { return "", false, ErrUnsupported }
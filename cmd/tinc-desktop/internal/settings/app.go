@@ -0,0 +1,148 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	appFile = "settings.json"
+	// current version of settings file layout. Increase it and add migration on incompatible changes
+	SchemaVersion = 1
+)
+
+const (
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+)
+
+const (
+	HelperAuto  = "auto" // detect first available privilege escalation helper
+	SpawnerAuto = "auto" // select spawner by privileges and platform
+)
+
+const (
+	UpdatesNotify = "notify"
+	UpdatesNever  = "never"
+)
+
+var ErrNewerSchema = errors.New("settings saved by newer version of application")
+
+// Global application preferences stored in config dir
+type App struct {
+	Version          int      `json:"version"`
+	DefaultSubnet    string   `json:"defaultSubnet"`
+	Autostart        bool     `json:"autostart"`
+	PrivilegeHelper  string   `json:"privilegeHelper"`
+	Spawner          string   `json:"spawner"`
+	Theme            string   `json:"theme"`
+	LogLevel         string   `json:"logLevel"`
+	MajordomoServers []string `json:"majordomoServers,omitempty"`
+	Updates          string   `json:"updates"`
+	StopTimeout      int      `json:"stopTimeout"` // seconds for tincd to stop gracefully before it is killed
+}
+
+func DefaultApp() *App {
+	return &App{
+		Version:         SchemaVersion,
+		DefaultSubnet:   "10.152.0.0/16",
+		PrivilegeHelper: HelperAuto,
+		Spawner:         SpawnerAuto,
+		Theme:           ThemeDark,
+		LogLevel:        "info",
+		Updates:         UpdatesNotify,
+		StopTimeout:     10,
+	}
+}
+
+// Time for network to stop gracefully before tincd is killed (zero for default)
+func (as *App) StopDeadline() time.Duration {
+	if as.StopTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(as.StopTimeout) * time.Second
+}
+
+// migrations[i] converts raw settings from version i to version i+1
+var migrations = []func(raw map[string]interface{}){
+	// unversioned file has the same layout as version 1
+	func(raw map[string]interface{}) {},
+}
+
+// Load application settings. Defaults are used if file not exists. Older versions are migrated and saved
+// (previous file kept as backup). Defaults are returned together with ErrNewerSchema for files from newer version.
+func LoadApp(configDir string) (*App, error) {
+	file := appSettingsFile(configDir)
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return DefaultApp(), nil
+	} else if err != nil {
+		return DefaultApp(), err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return DefaultApp(), fmt.Errorf("parse %s: %w", file, err)
+	}
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > SchemaVersion {
+		return DefaultApp(), ErrNewerSchema
+	}
+	for v := version; v < SchemaVersion; v++ {
+		migrations[v](raw)
+	}
+	raw["version"] = SchemaVersion
+	if data, err = json.Marshal(raw); err != nil {
+		return DefaultApp(), err
+	}
+	var ans = DefaultApp()
+	if err := json.Unmarshal(data, ans); err != nil {
+		return DefaultApp(), fmt.Errorf("parse %s: %w", file, err)
+	}
+	if version < SchemaVersion {
+		if err := os.Rename(file, file+".v"+strconv.Itoa(version)); err != nil {
+			return ans, err
+		}
+		if err := ans.Save(configDir); err != nil {
+			return ans, err
+		}
+	}
+	return ans, nil
+}
+
+func (as *App) Save(configDir string) error {
+	as.Version = SchemaVersion
+	data, err := json.MarshalIndent(as, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := appSettingsFile(configDir)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
+
+// Add majordomo server to known list if it is not there yet. Returns true if list changed
+func (as *App) AddMajordomo(server string) bool {
+	for _, known := range as.MajordomoServers {
+		if known == server {
+			return false
+		}
+	}
+	as.MajordomoServers = append(as.MajordomoServers, server)
+	return true
+}
+
+func appSettingsFile(configDir string) string {
+	return filepath.Join(configDir, appFile)
+}
@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"encoding/json"
+	"github.com/tinc-boot/tincd/network"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const networkFile = ".desktop.json"
+
+// tincd debug levels: peers are detected by tincd output and tincd is started with level 4 which could be only
+// raised to 5 at runtime (see internal.DebugLevel)
+const (
+	MinDebugLevel     = 4
+	DefaultDebugLevel = 4
+	MaxDebugLevel     = 5
+)
+
+// Restart policies of network worker
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+)
+
+// Colors of network label
+var Colors = map[string]color.RGBA{
+	"gray":   {R: 0x9e, G: 0x9e, B: 0x9e, A: 0xff},
+	"red":    {R: 0xe5, G: 0x39, B: 0x35, A: 0xff},
+	"orange": {R: 0xfb, G: 0x8c, B: 0x00, A: 0xff},
+	"green":  {R: 0x43, G: 0xa0, B: 0x47, A: 0xff},
+	"blue":   {R: 0x1e, G: 0x88, B: 0xe5, A: 0xff},
+	"purple": {R: 0x8e, G: 0x24, B: 0xaa, A: 0xff},
+}
+
+// Desktop metadata and settings of single network (not related to tinc configuration)
+type Network struct {
+	DisplayName   string    `json:"displayName,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Color         string    `json:"color,omitempty"` // key of Colors
+	Icon          string    `json:"icon,omitempty"`
+	JoinURL       string    `json:"joinUrl,omitempty"` // origin of network if joined
+	Autostart     bool      `json:"autostart"`
+	RestartPolicy string    `json:"restartPolicy"`
+	Tags          []string  `json:"tags,omitempty"`
+	Created       time.Time `json:"created"`
+	LastConnected time.Time `json:"lastConnected"`
+	DebugLevel    int       `json:"debugLevel"`
+}
+
+func DefaultNetwork() *Network {
+	return &Network{DebugLevel: DefaultDebugLevel, RestartPolicy: RestartNever, Color: "gray"}
+}
+
+// Name for UI: display name or network name
+func (ns *Network) Title(ntw *network.Network) string {
+	if ns.DisplayName != "" {
+		return ns.DisplayName
+	}
+	return ntw.Name()
+}
+
+func (ns *Network) RGBA() color.RGBA {
+	if c, ok := Colors[ns.Color]; ok {
+		return c
+	}
+	return Colors["gray"]
+}
+
+// Load, modify and save network settings
+func UpdateNetwork(ntw *network.Network, update func(ns *Network)) error {
+	ns, err := LoadNetwork(ntw)
+	if err != nil {
+		return err
+	}
+	update(ns)
+	return ns.Save(ntw)
+}
+
+// Load network settings. Defaults are used if settings are not saved yet
+func LoadNetwork(ntw *network.Network) (*Network, error) {
+	var ans = DefaultNetwork()
+	data, err := ioutil.ReadFile(networkSettingsFile(ntw))
+	if os.IsNotExist(err) {
+		return ans, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, ans); err != nil {
+		return nil, err
+	}
+	ans.DebugLevel = ClampDebugLevel(ans.DebugLevel)
+	return ans, nil
+}
+
+func (ns *Network) Save(ntw *network.Network) error {
+	data, err := json.MarshalIndent(ns, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := networkSettingsFile(ntw)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
+
+func ClampDebugLevel(level int) int {
+	if level < MinDebugLevel {
+		return MinDebugLevel
+	}
+	if level > MaxDebugLevel {
+		return MaxDebugLevel
+	}
+	return level
+}
+
+func networkSettingsFile(ntw *network.Network) string {
+	return filepath.Join(ntw.Root, networkFile)
+}
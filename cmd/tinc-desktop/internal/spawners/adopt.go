@@ -0,0 +1,91 @@
+package spawners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/api"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
+	"github.com/tinc-boot/tinc-desktop/sudo"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	adoptTimeout         = 3 * time.Second
+	processCheckInterval = time.Second
+)
+
+// Find worker of network left by previous instance of application by state file. Responding worker is adopted,
+// hung one is killed (tincd exits together with worker). Worker could be privileged, so it is killed with
+// privileges of escalation helper if needed. Returns nil port if there is no running worker
+func adopt(ctx context.Context, configLocation string, network string, helper string, done chan struct{}) (internal.Port, error) {
+	directory := filepath.Join(configLocation, network)
+	state, err := workerstate.Read(directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if internal.ProcessAlive(state.PID) {
+		checkCtx, cancel := context.WithTimeout(ctx, adoptTimeout)
+		workerstate.Authorize(state.Endpoint, state.Token)
+		_, err := (&api.WorkerClient{BaseURL: state.Endpoint}).Peers(checkCtx)
+		cancel()
+		if err == nil {
+			wp := newWorkerPort(network, state.Endpoint, state.Token, done)
+			wp.terminate = func() error { return killProcess(helper, state.PID) }
+			go func() {
+				for internal.ProcessAlive(state.PID) {
+					time.Sleep(processCheckInterval)
+				}
+				wp.finish(adoptedExit(directory, state.PID))
+			}()
+			return wp, nil
+		}
+		// refused connection means that PID is reused by another process after crash of worker
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Println(network, "worker", state.PID, "is not responding, killing")
+			if err := killProcess(helper, state.PID); err != nil {
+				return nil, fmt.Errorf("kill worker %d: %w", state.PID, err)
+			}
+		}
+	}
+	return nil, workerstate.Remove(directory)
+}
+
+// Exit code of not own process is unknown, but worker removes state file only on normal exit
+func adoptedExit(directory string, pid int) error {
+	state, err := workerstate.Read(directory)
+	if err != nil || state.PID != pid {
+		return nil
+	}
+	if err := workerstate.Remove(directory); err != nil {
+		log.Println("remove state of worker", pid, ":", err)
+	}
+	return fmt.Errorf("worker %d exited unexpectedly", pid)
+}
+
+func killProcess(helper string, pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	err = process.Kill()
+	if !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	cmd, err := sudo.Command(helper, killCommand(pid))
+	if err != nil {
+		return err
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", sudo.Reason(cmd, err), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package spawners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reddec/jsonrpc2"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/helperapi"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
+	"github.com/tinc-boot/tinc-desktop/sudo"
+	"github.com/tinc-boot/tincd/utils"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Spawns workers by single privileged helper process started on first use, so credentials are asked once
+// per session. Helper exits on Close or when application exits
+type SharedHelper struct {
+	ConfigLocation string
+	Logging        logging.Options
+	Helper         string // privilege escalation helper, empty for auto-detection
+	lock           sync.Mutex
+	client         *helperapi.HelperClient
+	exited         chan struct{}
+	err            error
+	process        *os.Process
+	listening      bool // helper responded at least once
+}
+
+func (sh *SharedHelper) Spawn(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	client, exited, err := sh.start()
+	if err != nil {
+		return nil, err
+	}
+	var endpoint *internal.Endpoint
+	for {
+		endpoint, err = client.Start(ctx, network)
+		var rpcErr *jsonrpc2.Error
+		if err == nil {
+			sh.markListening()
+			break
+		} else if errors.As(err, &rpcErr) {
+			sh.markListening()
+			return nil, rpcErr
+		}
+		// helper is not listening yet (user is entering password)
+		select {
+		case <-ctx.Done():
+			sh.cancelStart()
+			return nil, ctx.Err()
+		case <-exited:
+			return nil, sh.exitError()
+		case <-time.After(readRetryInterval):
+		}
+	}
+	wp := newWorkerPort(network, endpoint.URL, endpoint.Token, done)
+	wp.terminate = func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_, err := client.Terminate(ctx, network)
+		return err
+	}
+	go func() {
+		reason, err := client.Wait(context.Background(), network)
+		if err != nil {
+			wp.finish(fmt.Errorf("privileged helper: %w", err))
+		} else if reason != "" {
+			wp.finish(errors.New(reason))
+		} else {
+			wp.finish(nil)
+		}
+	}()
+	return wp, nil
+}
+
+func (sh *SharedHelper) Adopt(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	return adopt(ctx, sh.ConfigLocation, network, sh.Helper, done)
+}
+
+// Credentials are asked only to start helper
+func (sh *SharedHelper) NeedsAuthorization() bool {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if sh.client == nil {
+		return true
+	}
+	select {
+	case <-sh.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop helper and all workers started by it
+func (sh *SharedHelper) Close() error {
+	sh.lock.Lock()
+	client, exited := sh.client, sh.exited
+	sh.lock.Unlock()
+	if client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if _, err := client.Shutdown(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-exited:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start helper if it is not running
+func (sh *SharedHelper) start() (*helperapi.HelperClient, <-chan struct{}, error) {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if sh.client != nil {
+		select {
+		case <-sh.exited:
+		default:
+			return sh.client, sh.exited, nil
+		}
+	}
+	port := 32000 + rand.Intn(32000)
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	// helper runs as root: only the application should control it
+	token, tokenFile, err := workerstate.WriteToken(sh.ConfigLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+	var arguments = []string{executable, "-c", sh.ConfigLocation, "--helper-port", strconv.Itoa(port), "--parent-pid", strconv.Itoa(os.Getpid()), "--api-token-file", tokenFile}
+	arguments = append(arguments, sh.Logging.Args()...)
+	cmd, err := sudo.Command(sh.Helper, arguments)
+	if err != nil {
+		_ = os.Remove(tokenFile)
+		return nil, nil, err
+	}
+	utils.SetCmdAttrs(cmd)
+	if err := cmd.Start(); err != nil {
+		_ = os.Remove(tokenFile)
+		return nil, nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		sh.lock.Lock()
+		sh.err = sudo.Reason(cmd, err)
+		sh.lock.Unlock()
+		close(exited)
+	}()
+	endpoint := "http://127.0.0.1:" + strconv.Itoa(port)
+	workerstate.Authorize(endpoint, token)
+	sh.client = &helperapi.HelperClient{BaseURL: endpoint}
+	sh.exited = exited
+	sh.process = cmd.Process
+	sh.listening = false
+	return sh.client, exited, nil
+}
+
+func (sh *SharedHelper) markListening() {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	sh.listening = true
+}
+
+// kill helper if start is cancelled during escalation, so prompt of credentials is closed
+func (sh *SharedHelper) cancelStart() {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if sh.listening || sh.process == nil {
+		return
+	}
+	if err := sh.process.Kill(); err != nil {
+		log.Println("kill privileged helper:", err)
+	}
+}
+
+func (sh *SharedHelper) exitError() error {
+	sh.lock.Lock()
+	defer sh.lock.Unlock()
+	if sh.err != nil {
+		return fmt.Errorf("privileged helper: %w", sh.err)
+	}
+	return errors.New("privileged helper exited")
+}
@@ -0,0 +1,9 @@
+// +build darwin linux
+
+package spawners
+
+import "strconv"
+
+func killCommand(pid int) []string {
+	return []string{"kill", "-KILL", strconv.Itoa(pid)}
+}
@@ -0,0 +1,7 @@
+package spawners
+
+import "strconv"
+
+func killCommand(pid int) []string {
+	return []string{"taskkill", "/F", "/PID", strconv.Itoa(pid)}
+}
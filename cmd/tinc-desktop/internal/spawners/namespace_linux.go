@@ -0,0 +1,24 @@
+package spawners
+
+import (
+	"context"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netns"
+)
+
+func init() {
+	Register("namespace", "only VPN interface is moved to namespace tinc-<network>, tincd and its connections to peers stay in host namespace", func(opts Options) internal.Spawner {
+		return &Namespace{SubProcess: SubProcess{ConfigLocation: opts.ConfigLocation, Logging: opts.Logging, Helper: opts.Helper}}
+	})
+}
+
+// Moves TUN interface of each network to own network namespace (see netns.Name). tincd itself is not isolated:
+// it runs and connects to peers from host namespace, but VPN interface is visible only inside namespace, so only
+// applications started there are routed through network
+type Namespace struct {
+	SubProcess
+}
+
+func (ns *Namespace) Spawn(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	return ns.SubProcess.spawn(ctx, network, done, "--netns", netns.Name(network))
+}
@@ -0,0 +1,80 @@
+package spawners
+
+import (
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"sort"
+	"sync"
+)
+
+// Select spawner by privileges and platform
+const Auto = "auto"
+
+// Parameters of spawners
+type Options struct {
+	ConfigLocation string
+	Logging        logging.Options
+	Helper         string // privilege escalation helper, empty for auto-detection
+}
+
+type Factory func(opts Options) internal.Spawner
+
+var (
+	registryLock sync.Mutex
+	registry     = map[string]Factory{}
+	descriptions = map[string]string{}
+)
+
+func init() {
+	Register("same-process", "tincd is started by application itself (application should have privileges)", func(opts Options) internal.Spawner {
+		return &SameProcess{ConfigLocation: opts.ConfigLocation, Logging: opts.Logging}
+	})
+	Register("subprocess", "privileged worker process per network (credentials are asked on each start)", func(opts Options) internal.Spawner {
+		return &SubProcess{ConfigLocation: opts.ConfigLocation, Logging: opts.Logging, Helper: opts.Helper}
+	})
+	Register("helper", "single privileged helper starts workers of all networks (credentials are asked once)", func(opts Options) internal.Spawner {
+		return &SharedHelper{ConfigLocation: opts.ConfigLocation, Logging: opts.Logging, Helper: opts.Helper}
+	})
+}
+
+// Register spawner by name with short description for user. Previous spawner with the same name is replaced
+func Register(name string, description string, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry[name] = factory
+	descriptions[name] = description
+}
+
+// Description of registered spawner (empty for unknown)
+func Description(name string) string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	return descriptions[name]
+}
+
+// Names of registered spawners (without Auto)
+func Names() []string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	var ans = make([]string, 0, len(registry))
+	for name := range registry {
+		ans = append(ans, name)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// Create spawner by name. Empty name or Auto means selection by privileges and platform
+func New(name string, opts Options) (internal.Spawner, error) {
+	if name == "" || name == Auto {
+		return SelectSpawner(opts.ConfigLocation, opts.Logging, opts.Helper), nil
+	}
+	registryLock.Lock()
+	factory, ok := registry[name]
+	registryLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown spawner %s", name)
+	}
+	return factory(opts), nil
+}
@@ -3,30 +3,77 @@ package spawners
 import (
 	"context"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/diag"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
 	"github.com/tinc-boot/tincd"
+	ntw "github.com/tinc-boot/tincd/network"
+	"os"
 	"path/filepath"
+	"time"
 )
 
 type SameProcess struct {
 	ConfigLocation string
+	Logging        logging.Options
 }
 
-func (sp *SameProcess) Spawn(network string, done chan struct{}) (internal.Port, error) {
-	instance, err := tincd.StartFromDir(context.Background(), filepath.Join(sp.ConfigLocation, network), false)
+func (sp *SameProcess) Spawn(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	directory := filepath.Join(sp.ConfigLocation, network)
+	lock, err := netlock.Acquire(directory)
 	if err != nil {
 		return nil, err
 	}
-
+	output := &logging.Buffer{}
+	logger, logfile, err := sp.Logging.Open(logging.NetworkFile(sp.ConfigLocation, network), output)
+	if err != nil {
+		_ = lock.Release()
+		return nil, err
+	}
+	// output of previous run should not be forwarded again
+	_ = os.Remove(logging.TincdFile(directory))
+	instance, err := internal.StartTincd(context.Background(), &ntw.Network{Root: directory})
+	if err != nil {
+		_ = logfile.Close()
+		_ = lock.Release()
+		return nil, err
+	}
+	debug := internal.NewDebugLevel(instance.Definition().Pidfile())
+	if ns, err := settings.LoadNetwork(instance.Definition()); err != nil {
+		logger.Warn("failed load network settings", "network", network, "error", err)
+	} else if err := debug.Set(ctx, ns.DebugLevel); err != nil {
+		instance.Stop()
+		<-instance.Done()
+		_ = logfile.Close()
+		_ = lock.Release()
+		return nil, err
+	}
 	port := &samePort{
-		client: tincdPort{client: instance},
+		client: tincdPort{client: instance, output: output, debug: debug},
 		done:   done,
 		name:   network,
 	}
 
+	followCtx, stopFollow := context.WithCancel(context.Background())
+	followed := make(chan struct{})
+	go func() {
+		defer close(followed)
+		tincdLogger := logger.With("network", network, "component", "tincd")
+		logging.FollowTincd(followCtx, directory, tincdLogger, tincdLogger.Fork(output, logging.LevelDebug))
+	}()
+
 	go func() {
 		defer close(port.done)
 		<-instance.Done()
-		port.err = instance.Error()
+		stopFollow()
+		<-followed
+		port.err = internal.WithOutput(instance.Error(), output)
+		_ = logfile.Close()
+		_ = lock.Release()
 	}()
 
 	return port, nil
@@ -44,8 +91,16 @@ func (wp *samePort) Name() string          { return wp.name }
 func (wp *samePort) Done() <-chan struct{} { return wp.done }
 func (wp *samePort) API() internal.Worker  { return &wp.client }
 
+// There is no worker process: tincd is killed
+func (wp *samePort) Terminate() error {
+	wp.client.client.Stop()
+	return nil
+}
+
 type tincdPort struct {
 	client tincd.Tincd
+	output *logging.Buffer
+	debug  *internal.DebugLevel
 }
 
 func (t *tincdPort) Kill(ctx context.Context) (bool, error) {
@@ -58,6 +113,28 @@ func (t *tincdPort) Kill(ctx context.Context) (bool, error) {
 	return true, t.client.Error()
 }
 
+func (t *tincdPort) Stop(ctx context.Context, timeout time.Duration) (internal.StopStep, error) {
+	return internal.StopTincd(ctx, t.client, timeout)
+}
+
 func (t *tincdPort) Peers(ctx context.Context) ([]string, error) {
 	return t.client.Peers(), nil
 }
+
+func (t *tincdPort) Reload(ctx context.Context) (bool, error) {
+	err := internal.Reload(t.client.Definition().Pidfile())
+	return err == nil, err
+}
+
+func (t *tincdPort) Diagnose(ctx context.Context, node string) (*internal.Diagnostics, error) {
+	return diag.Node(ctx, t.client.Definition(), node, diag.DefaultCount)
+}
+
+func (t *tincdPort) Logs(ctx context.Context, after uint64) ([]internal.LogLine, error) {
+	return internal.ReadLogs(ctx, t.output, after), nil
+}
+
+func (t *tincdPort) SetDebugLevel(ctx context.Context, level int) (bool, error) {
+	err := t.debug.Set(ctx, level)
+	return err == nil, err
+}
@@ -4,12 +4,17 @@ package spawners
 
 import (
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/privileges"
 	"os"
 )
 
-func SelectSpawner(configLocation string) internal.Spawner {
+func SelectSpawner(configLocation string, logs logging.Options, helper string) internal.Spawner {
 	if os.Geteuid() == 0 {
-		return &SameProcess{ConfigLocation: configLocation}
+		return &SameProcess{ConfigLocation: configLocation, Logging: logs}
 	}
-	return &SubProcess{ConfigLocation: configLocation}
+	if privileges.HasNetAdmin() {
+		return &SubProcess{ConfigLocation: configLocation, Logging: logs, Direct: true}
+	}
+	return &SharedHelper{ConfigLocation: configLocation, Logging: logs, Helper: helper}
 }
@@ -2,8 +2,9 @@ package spawners
 
 import (
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
 )
 
-func SelectSpawner(configLocation string) internal.Spawner {
-	return &SameProcess{ConfigLocation: configLocation}
+func SelectSpawner(configLocation string, logs logging.Options, helper string) internal.Spawner {
+	return &SameProcess{ConfigLocation: configLocation, Logging: logs}
 }
@@ -1,58 +1,195 @@
 package spawners
 
 import (
+	"context"
+	"errors"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/api"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
 	"github.com/tinc-boot/tinc-desktop/sudo"
+	ntw "github.com/tinc-boot/tincd/network"
 	"github.com/tinc-boot/tincd/utils"
+	"log"
 	"math/rand"
+	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"time"
 )
 
+const readRetryInterval = 300 * time.Millisecond
+
 type SubProcess struct {
 	ConfigLocation string
+	Logging        logging.Options
+	Helper         string // privilege escalation helper, empty for auto-detection
+	Direct         bool   // executable has enough capabilities (setcap), start worker without escalation
+}
+
+func (sp *SubProcess) Spawn(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	return sp.spawn(ctx, network, done)
 }
 
-func (sp *SubProcess) Spawn(network string, done chan struct{}) (internal.Port, error) {
+func (sp *SubProcess) NeedsAuthorization() bool { return !sp.Direct }
+
+func (sp *SubProcess) Adopt(ctx context.Context, network string, done chan struct{}) (internal.Port, error) {
+	return adopt(ctx, sp.ConfigLocation, network, sp.Helper, done)
+}
+
+// start worker with extra arguments
+func (sp *SubProcess) spawn(ctx context.Context, network string, done chan struct{}, extra ...string) (internal.Port, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	port := 32000 + rand.Intn(32000)
 
 	executable, err := os.Executable()
 	if err != nil {
 		return nil, err
 	}
-	var arguments = []string{executable, "-c", sp.ConfigLocation, "-p", strconv.Itoa(port), "-n", network}
-	cmdParams := sudo.WithSudo(arguments)
-	cmd := exec.Command(cmdParams[0], cmdParams[1:]...)
+	token, tokenFile, err := workerstate.WriteToken(filepath.Join(sp.ConfigLocation, network))
+	if err != nil {
+		return nil, err
+	}
+	var arguments = []string{executable, "-c", sp.ConfigLocation, "-p", strconv.Itoa(port), "-n", network, "--api-token-file", tokenFile}
+	arguments = append(arguments, sp.Logging.Args()...)
+	arguments = append(arguments, extra...)
+	if ns, err := settings.LoadNetwork(&ntw.Network{Root: filepath.Join(sp.ConfigLocation, network)}); err == nil {
+		arguments = append(arguments, "--tincd-debug", strconv.Itoa(ns.DebugLevel))
+	}
+	cmd := exec.Command(arguments[0], arguments[1:]...)
+	if !sp.Direct {
+		cmd, err = sudo.Command(sp.Helper, arguments)
+		if err != nil {
+			_ = os.Remove(tokenFile)
+			return nil, err
+		}
+	}
 	utils.SetCmdAttrs(cmd)
 	err = cmd.Start()
 	if err != nil {
+		_ = os.Remove(tokenFile)
 		return nil, err
 	}
 
-	wp := &workerPort{
-		client: &api.WorkerClient{BaseURL: "http://127.0.0.1:" + strconv.Itoa(port)},
-		done:   done,
-		name:   network,
+	var exitErr error
+	exited := make(chan struct{})
+	go func() {
+		exitErr = cmd.Wait()
+		close(exited)
+	}()
+	// escalation helper could wait for password till cancel
+	if err := waitListening(ctx, "127.0.0.1:"+strconv.Itoa(port), exited); err != nil {
+		if err := cmd.Process.Kill(); err != nil {
+			log.Println(network, "kill cancelled worker:", err)
+		}
+		_ = os.Remove(tokenFile)
+		return nil, err
 	}
 
+	wp := newWorkerPort(network, "http://127.0.0.1:"+strconv.Itoa(port), token, done)
+	wp.terminate = cmd.Process.Kill
 	go func() {
-		wp.err = cmd.Wait()
-		close(wp.done)
+		<-exited
+		wp.finish(internal.WithOutput(sudo.Reason(cmd, exitErr), &wp.output))
 	}()
 
 	return wp, nil
 }
 
+// wait till API of worker accepts connections or worker exits
+func waitListening(ctx context.Context, address string, exited <-chan struct{}) error {
+	for {
+		if conn, err := net.DialTimeout("tcp", address, readRetryInterval); err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-exited:
+			return nil
+		case <-time.After(readRetryInterval):
+		}
+	}
+}
+
 type workerPort struct {
-	client internal.Worker
-	done   chan struct{}
-	name   string
-	err    error
+	client      *api.WorkerClient
+	done        chan struct{}
+	name        string
+	err         error
+	output      logging.Buffer
+	stopReading func()
+	read        chan struct{}
+	terminate   func() error
+	token       string
+}
+
+// Port of worker by API endpoint. Output of worker is read by API, because output of escalated process
+// is not available directly
+func newWorkerPort(network string, endpoint string, token string, done chan struct{}) *workerPort {
+	workerstate.Authorize(endpoint, token)
+	readCtx, stopReading := context.WithCancel(context.Background())
+	wp := &workerPort{
+		client:      &api.WorkerClient{BaseURL: endpoint},
+		token:       token,
+		done:        done,
+		name:        network,
+		stopReading: stopReading,
+		read:        make(chan struct{}),
+	}
+	go func() {
+		defer close(wp.read)
+		wp.readOutput(readCtx)
+	}()
+	return wp
+}
+
+// Mark worker exited with error
+func (wp *workerPort) finish(err error) {
+	wp.stopReading()
+	<-wp.read
+	wp.err = err
+	close(wp.done)
+}
+
+func (wp *workerPort) readOutput(ctx context.Context) {
+	var after uint64
+	for {
+		lines, err := wp.client.Logs(ctx, after)
+		if err != nil {
+			// worker is not listening yet
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(readRetryInterval):
+			}
+			continue
+		}
+		for _, line := range lines {
+			wp.output.Add(line.Text)
+			after = line.ID
+		}
+	}
 }
 
 func (wp *workerPort) Error() error          { return wp.err }
 func (wp *workerPort) Name() string          { return wp.name }
 func (wp *workerPort) Done() <-chan struct{} { return wp.done }
 func (wp *workerPort) API() internal.Worker  { return wp.client }
+func (wp *workerPort) Endpoint() *internal.Endpoint {
+	return &internal.Endpoint{URL: wp.client.BaseURL, Token: wp.token}
+}
+
+// Kill worker process which doesn't respond or doesn't exit
+func (wp *workerPort) Terminate() error {
+	if wp.terminate == nil {
+		return errors.New("worker could not be terminated")
+	}
+	return wp.terminate()
+}
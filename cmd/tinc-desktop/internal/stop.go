@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"context"
+	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tincd/network"
+	"time"
+)
+
+// Start tincd of network in background (replaced by fake in tests)
+var StartTincd = func(ctx context.Context, ntw *network.Network) (tincd.Tincd, error) {
+	return tincd.Start(ctx, ntw, false)
+}
+
+// Stop tincd by signal (tinc-down is executed) and kill it if it is not stopped in timeout or signal is
+// not supported
+func StopTincd(ctx context.Context, instance tincd.Tincd, timeout time.Duration) (StopStep, error) {
+	if err := Terminate(instance.Definition().Pidfile()); err == nil {
+		select {
+		case <-instance.Done():
+			return StepGraceful, nil
+		case <-ctx.Done():
+		case <-time.After(timeout):
+		}
+	}
+	instance.Stop()
+	select {
+	case <-instance.Done():
+		return StepForced, nil
+	case <-ctx.Done():
+		return StepForced, ctx.Err()
+	}
+}
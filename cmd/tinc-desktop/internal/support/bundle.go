@@ -0,0 +1,239 @@
+package support
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tincd/network"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	statusTimeout  = 3 * time.Second
+	commandTimeout = 10 * time.Second
+)
+
+const readme = `Support bundle of tinc-desktop
+
+logs/        application and network logs (current and previous session)
+networks/    tinc.conf, tinc-up, tinc-down and host files of networks
+status.txt   state of networks and their peers
+system/      output of system network commands
+version.txt  version of application and system
+
+Removed from files:
+- private keys (PEM blocks and PrivateKey options)
+- values of tokens (auth tokens of application API)
+- path and query of URLs (join codes of majordomo servers)
+- long hex and base64 strings in logs (keys and tokens)
+
+Kept as is: host names and addresses of nodes and majordomo servers, public keys in host files, VPN and
+public IP addresses, names of nodes and networks, system network configuration.
+`
+
+// Source of running workers (manager of application)
+type Workers interface {
+	Find(name string) internal.Port
+	State(name string) (manager.State, error)
+}
+
+// Archive with everything needed to investigate problems: sanitized logs and configuration (without private
+// keys), workers status, system network configuration and version (see readme)
+type Bundle struct {
+	ConfigDir string
+	Version   string
+	Workers   Workers // optional
+}
+
+// Save bundle as zip archive to file
+func (b *Bundle) Save(ctx context.Context, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := b.Write(ctx, f); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return network.ApplyOwnerOfSudoUser(file)
+}
+
+// Default location of new bundle
+func (b *Bundle) DefaultFile() string {
+	return filepath.Join(b.ConfigDir, "support-"+time.Now().Format("20060102T150405")+".zip")
+}
+
+// Write bundle as zip archive. Problems with single items are written to errors.txt instead of failing
+func (b *Bundle) Write(ctx context.Context, out io.Writer) error {
+	archive := zip.NewWriter(out)
+	var problems []string
+	add := func(name string, content []byte) {
+		w, err := archive.Create(name)
+		if err == nil {
+			_, err = w.Write(content)
+		}
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+		}
+	}
+
+	add("README.txt", []byte(readme))
+	add("version.txt", []byte(b.version()))
+	for name, file := range b.logFiles() {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+			continue
+		}
+		add(name, SanitizeLog(content))
+	}
+
+	networks, err := internal.Networks(b.ConfigDir)
+	if err != nil {
+		problems = append(problems, "list networks: "+err.Error())
+	}
+	var status bytes.Buffer
+	for _, ntw := range networks {
+		for name, file := range configFiles(ntw) {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				problems = append(problems, name+": "+err.Error())
+				continue
+			}
+			add(name, Sanitize(content))
+		}
+		status.WriteString(b.status(ctx, ntw))
+	}
+	add("status.txt", status.Bytes())
+	add("interfaces.txt", []byte(interfaces()))
+	for _, command := range systemCommands {
+		add("system/"+strings.Join(command, "_")+".txt", run(ctx, command))
+	}
+	if len(problems) > 0 {
+		add("errors.txt", []byte(strings.Join(problems, "\n")+"\n"))
+	}
+	return archive.Close()
+}
+
+func (b *Bundle) version() string {
+	return fmt.Sprintf("version: %s\ngo: %s\nos: %s\narch: %s\ncreated: %s\n", b.Version, runtime.Version(), runtime.GOOS, runtime.GOARCH, time.Now().Format(time.RFC3339))
+}
+
+// current log files and previous file of each log (usually session with the problem)
+func (b *Bundle) logFiles() map[string]string {
+	var ans = make(map[string]string)
+	var logs = []string{logging.AppFile(b.ConfigDir)}
+	if list, err := filepath.Glob(logging.NetworkFile(b.ConfigDir, "*")); err == nil {
+		logs = append(logs, list...)
+	}
+	for _, file := range logs {
+		files := []string{file}
+		rotating := &logging.RotatingFile{Path: file}
+		if backups, err := rotating.Backups(); err == nil && len(backups) > 0 {
+			files = append(files, backups[0])
+		}
+		for _, item := range files {
+			if _, err := os.Stat(item); err != nil {
+				continue
+			}
+			name := "logs/" + filepath.Base(item)
+			if filepath.Base(filepath.Dir(item)) != "logs" {
+				name = "logs/" + filepath.Base(filepath.Dir(item)) + "/" + filepath.Base(item)
+			}
+			ans[name] = item
+		}
+	}
+	return ans
+}
+
+func (b *Bundle) status(ctx context.Context, ntw *network.Network) string {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[%s]\n", ntw.Name())
+	if pid, err := ioutil.ReadFile(ntw.Pidfile()); err == nil {
+		fmt.Fprintf(&out, "pidfile: %s\n", strings.TrimSpace(string(pid)))
+	} else {
+		fmt.Fprintf(&out, "pidfile: %v\n", err)
+	}
+	if b.Workers == nil {
+		out.WriteString("worker: unknown (not managed by this process)\n\n")
+		return out.String()
+	}
+	state, stateErr := b.Workers.State(ntw.Name())
+	fmt.Fprintf(&out, "state: %s\n", state)
+	if stateErr != nil {
+		fmt.Fprintf(&out, "error: %v\n", stateErr)
+	}
+	port := b.Workers.Find(ntw.Name())
+	if port == nil || !state.Active() {
+		out.WriteString("\n")
+		return out.String()
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+	peers, err := port.API().Peers(ctx)
+	if err != nil {
+		fmt.Fprintf(&out, "peers: %v\n\n", err)
+	} else {
+		fmt.Fprintf(&out, "peers: %s\n\n", strings.Join(peers, ", "))
+	}
+	return out.String()
+}
+
+// configuration files of network except private keys, history and backups
+func configFiles(ntw *network.Network) map[string]string {
+	var ans = make(map[string]string)
+	prefix := "networks/" + ntw.Name() + "/"
+	ans[prefix+"tinc.conf"] = filepath.Join(ntw.Root, "tinc.conf")
+	for _, pattern := range []string{"tinc-up*", "tinc-down*", "hosts/*"} {
+		list, _ := filepath.Glob(filepath.Join(ntw.Root, pattern))
+		for _, file := range list {
+			if info, err := os.Stat(file); err != nil || info.IsDir() {
+				continue
+			}
+			rel, _ := filepath.Rel(ntw.Root, file)
+			ans[prefix+filepath.ToSlash(rel)] = file
+		}
+	}
+	return ans
+}
+
+func interfaces() string {
+	list, err := net.Interfaces()
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	var out bytes.Buffer
+	for _, iface := range list {
+		fmt.Fprintf(&out, "%s mtu=%d flags=%v\n", iface.Name, iface.MTU, iface.Flags)
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			fmt.Fprintf(&out, "  %s\n", addr)
+		}
+	}
+	return out.String()
+}
+
+func run(ctx context.Context, command []string) []byte {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		output = append(output, []byte("\n"+err.Error()+"\n")...)
+	}
+	return output
+}
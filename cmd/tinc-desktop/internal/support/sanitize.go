@@ -0,0 +1,91 @@
+package support
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+const (
+	removed     = "# removed from support bundle"
+	removedText = "<removed>"
+)
+
+var (
+	tokenPattern  = regexp.MustCompile(`(?i)(token["']?\s*[=:]\s*["']?)[^\s"'&,;]+`)
+	urlPattern    = regexp.MustCompile(`(https?://[^/\s"'?#]+)([/?#][^\s"',]*)`)
+	secretPattern = regexp.MustCompile(`[A-Za-z0-9+_=-]{32,}`)
+)
+
+// Remove private keys (PEM blocks and PrivateKey* options) from configuration file content
+func Sanitize(content []byte) []byte {
+	var out bytes.Buffer
+	var inPrivate bool
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inPrivate:
+			if strings.HasPrefix(trimmed, "-----END") {
+				inPrivate = false
+			}
+			continue
+		case strings.HasPrefix(trimmed, "-----BEGIN") && strings.Contains(trimmed, "PRIVATE KEY"):
+			inPrivate = true
+			line = removed
+		case strings.HasPrefix(strings.ToLower(trimmed), "privatekey"):
+			line = removed
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// Remove secrets from log file content: private keys (as in configuration), values of tokens (auth tokens of
+// API), path and query of URLs (join codes of majordomo) and long hex or base64 strings (keys and tokens
+// printed by tincd). Host names, IP addresses and node names are kept
+func SanitizeLog(content []byte) []byte {
+	content = Sanitize(content)
+	content = tokenPattern.ReplaceAll(content, []byte("${1}"+removedText))
+	content = urlPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := urlPattern.FindSubmatch(match)
+		if string(groups[2]) == "/" {
+			return match
+		}
+		return []byte(string(groups[1]) + "/" + removedText)
+	})
+	return secretPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		if secret(match) {
+			return []byte(removedText)
+		}
+		return match
+	})
+}
+
+// hex string or base64 with mixed case and digits (not a long word or name)
+func secret(text []byte) bool {
+	var digit, lower, upper, other bool
+	for _, c := range text {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F':
+			if c >= 'a' {
+				lower = true
+			} else {
+				upper = true
+			}
+		case c >= 'a' && c <= 'z':
+			lower, other = true, true
+		case c >= 'A' && c <= 'Z':
+			upper, other = true, true
+		}
+	}
+	if !other {
+		return digit
+	}
+	return digit && lower && upper
+}
@@ -0,0 +1,7 @@
+package support
+
+var systemCommands = [][]string{
+	{"ip", "addr"},
+	{"ip", "route"},
+	{"ip", "-6", "route"},
+}
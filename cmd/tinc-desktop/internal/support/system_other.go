@@ -0,0 +1,8 @@
+// +build !linux,!windows
+
+package support
+
+var systemCommands = [][]string{
+	{"ifconfig", "-a"},
+	{"netstat", "-rn"},
+}
@@ -0,0 +1,6 @@
+package support
+
+var systemCommands = [][]string{
+	{"ipconfig", "/all"},
+	{"route", "print"},
+}
@@ -0,0 +1,72 @@
+// Package updates checks published releases of application
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Latest release of application on GitHub
+const LatestURL = "https://api.github.com/repos/tinc-boot/tinc-desktop/releases/latest"
+
+type Release struct {
+	Version string `json:"tag_name"`
+	URL     string `json:"html_url"` // page with downloads
+}
+
+// Latest published release
+func Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequest(http.MethodGet, LatestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("check releases: %s", res.Status)
+	}
+	var release Release
+	return &release, json.NewDecoder(res.Body).Decode(&release)
+}
+
+// Newer checks that release is above current version. Versions are compared by numeric parts (v1.2.3), so
+// development builds without version are never updated
+func (r *Release) Newer(current string) bool {
+	latest, ok := parse(r.Version)
+	if !ok {
+		return false
+	}
+	installed, ok := parse(current)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(latest) && i < len(installed); i++ {
+		if latest[i] != installed[i] {
+			return latest[i] > installed[i]
+		}
+	}
+	return len(latest) > len(installed)
+}
+
+func parse(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var ans []int
+	for _, part := range strings.Split(version, ".") {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		ans = append(ans, v)
+	}
+	return ans, true
+}
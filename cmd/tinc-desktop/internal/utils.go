@@ -2,4 +2,63 @@
 
 package internal
 
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
 func CanStart() bool { return true }
+
+// Reload asks running tincd (found by PID file) to re-read configuration and host files
+func Reload(pidfile string) error {
+	return signal(pidfile, syscall.SIGHUP)
+}
+
+// ToggleDebug switches debug level of running tincd (found by PID file) between 5 and level of start
+func ToggleDebug(pidfile string) error {
+	return signal(pidfile, syscall.SIGINT)
+}
+
+// Terminate asks running tincd (found by PID file) to exit
+func Terminate(pidfile string) error {
+	return signal(pidfile, syscall.SIGTERM)
+}
+
+// Killed tincd leaves PID file, so PID could be reused by unrelated process. Only tincd started by this
+// process is signaled
+func signal(pidfile string, sig syscall.Signal) error {
+	pid, err := readPid(pidfile)
+	if err != nil {
+		return err
+	}
+	parent, err := parentPid(pid)
+	if err != nil {
+		return fmt.Errorf("check process %d: %w", pid, err)
+	}
+	if parent != os.Getpid() {
+		return fmt.Errorf("process %d from %s is not tincd of this instance", pid, pidfile)
+	}
+	return syscall.Kill(pid, sig)
+}
+
+func readPid(pidfile string) (int, error) {
+	data, err := ioutil.ReadFile(pidfile)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty pid file %s", pidfile)
+	}
+	return strconv.Atoi(fields[0])
+}
+
+// Process with PID exists (signal 0 could be delivered)
+func ProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
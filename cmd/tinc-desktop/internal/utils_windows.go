@@ -1,6 +1,9 @@
 package internal
 
-import "os"
+import (
+	"errors"
+	"os"
+)
 
 func CanStart() bool {
 	_, err := os.Open("\\\\.\\PHYSICALDRIVE0")
@@ -9,3 +12,28 @@ func CanStart() bool {
 	}
 	return true
 }
+
+// Reload is not supported on Windows: tincd has no signal handling there
+func Reload(pidfile string) error {
+	return errors.New("reload is not supported on windows")
+}
+
+// ToggleDebug is not supported on Windows: debug level of tincd could be only set on start
+func ToggleDebug(pidfile string) error {
+	return errors.New("change of debug level is not supported on windows")
+}
+
+// Terminate is not supported on Windows: tincd could be only killed
+func Terminate(pidfile string) error {
+	return errors.New("terminate is not supported on windows")
+}
+
+// Process with PID exists
+func ProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,52 @@
+package workerstate
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Header of API request with auth token. Token is not a part of URL, because URL is included in errors of
+// API client and errors are logged
+const TokenHeader = "X-Api-Token"
+
+var (
+	authLock  sync.Mutex
+	authHosts = make(map[string]string) // host:port -> token
+	authOnce  sync.Once
+)
+
+// Authorize requests of default HTTP client (used by API clients) to endpoint by token
+func Authorize(endpoint string, token string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || token == "" {
+		return
+	}
+	authOnce.Do(func() {
+		http.DefaultClient.Transport = &authTransport{base: http.DefaultClient.Transport}
+	})
+	authLock.Lock()
+	authHosts[u.Host] = token
+	authLock.Unlock()
+}
+
+type authTransport struct {
+	base http.RoundTripper
+}
+
+func (at *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authLock.Lock()
+	token, ok := authHosts[req.URL.Host]
+	authLock.Unlock()
+	base := at.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	// round tripper should not modify original request
+	req = req.Clone(req.Context())
+	req.Header.Set(TokenHeader, token)
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,21 @@
+// +build !windows
+
+package workerstate
+
+import (
+	"os"
+	"syscall"
+)
+
+// privileged worker writes file readable by application
+func chownAsDir(file string, directory string) error {
+	info, err := os.Stat(directory)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) == os.Getuid() {
+		return nil
+	}
+	return os.Lchown(file, int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,3 @@
+package workerstate
+
+func chownAsDir(file string, directory string) error { return nil }
@@ -0,0 +1,101 @@
+// Package workerstate keeps state of running worker in network directory, so worker could be found
+// by next instance of application (after crash)
+package workerstate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	fileName  = ".worker.json"
+	tokenName = ".worker.token"
+)
+
+// Running worker of network
+type State struct {
+	PID      int       `json:"pid"`
+	Endpoint string    `json:"endpoint"` // base URL of API
+	Token    string    `json:"token"`
+	Started  time.Time `json:"started"`
+}
+
+// Random auth token for worker API
+func NewToken() (string, error) {
+	var data [16]byte
+	if _, err := rand.Read(data[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data[:]), nil
+}
+
+// Write new auth token to file for worker (only owner could read it). Returns token and file
+func WriteToken(directory string) (string, string, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	file := filepath.Join(directory, tokenName)
+	_ = os.Remove(file) // permissions of existing file are not changed on write
+	return token, file, ioutil.WriteFile(file, []byte(token), 0600)
+}
+
+// Read auth token and remove file, so it is used once
+func ReadToken(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("empty token")
+	}
+	return token, os.Remove(file)
+}
+
+func File(directory string) string {
+	return filepath.Join(directory, fileName)
+}
+
+// Save state of worker. File is readable by owner of network directory only (token is secret)
+func Write(directory string, st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := File(directory)
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := chownAsDir(tmp, directory); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Read state of worker. Error satisfies os.IsNotExist if there is no worker
+func Read(directory string) (*State, error) {
+	data, err := ioutil.ReadFile(File(directory))
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	return &st, json.Unmarshal(data, &st)
+}
+
+func Remove(directory string) error {
+	err := os.Remove(File(directory))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
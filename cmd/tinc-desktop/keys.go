@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"github.com/tinc-boot/tinc-desktop/api/tincwebmajordomo"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/history"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/keys"
+	"github.com/tinc-boot/tincd/network"
+	"io/ioutil"
+	"log"
+)
+
+func rotateKeys(ntw *network.Network) (*network.Node, error) {
+	var self *network.Node
+	err := history.Of(ntw).Track("keys rotated", func() error {
+		var err error
+		self, err = keys.Rotate(ntw)
+		return err
+	})
+	return self, err
+}
+
+func revertKeys(ntw *network.Network) error {
+	return history.Of(ntw).Track("keys rotation reverted", func() error {
+		return keys.Revert(ntw)
+	})
+}
+
+// push self host file to majordomo server by join URL and import nodes shared in reply.
+// Returns names of verified peers which keys are changed
+func pushSelf(ctx context.Context, ntw *network.Network, url string) ([]string, error) {
+	share, err := decodeJoinURL(url)
+	if err != nil {
+		return nil, err
+	}
+	self, err := ntw.Self()
+	if err != nil {
+		return nil, err
+	}
+	remote := &tincwebmajordomo.TincWebMajordomoClient{BaseURL: url}
+	ctx, cancel := context.WithTimeout(ctx, joinTimeout)
+	defer cancel()
+	sharedNet, err := remote.Join(ctx, share.Network, self)
+	if err != nil {
+		return nil, err
+	}
+	return importNodes(ntw, sharedNet.Nodes, "synced with "+share.Network)
+}
+
+// save nodes definitions. Returns names of verified peers which keys are changed
+func importNodes(ntw *network.Network, nodes []*network.Node, reason string) ([]string, error) {
+	verified, err := keys.LoadVerified(ntw)
+	if err != nil {
+		return nil, err
+	}
+	changed := verified.Changed(nodes)
+	for _, name := range changed {
+		log.Println("WARNING: key of verified peer", name, "changed")
+	}
+	return changed, history.Of(ntw).Track(reason, func() error {
+		for _, node := range nodes {
+			if err := ntw.Put(node); err != nil {
+				log.Println(node.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// content of self host file to share with peers
+func exportSelf(ntw *network.Network) ([]byte, error) {
+	self, err := ntw.Self()
+	if err != nil {
+		return nil, err
+	}
+	return self.Build()
+}
+
+func exportSelfToFile(ntw *network.Network, file string) error {
+	data, err := exportSelf(ntw)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
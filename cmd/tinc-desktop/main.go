@@ -2,28 +2,49 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"fyne.io/fyne"
 	"fyne.io/fyne/app"
 	"github.com/jessevdk/go-flags"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/instance"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/privileges"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/spawners"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime/debug"
 	"time"
 )
 
+// set by linker flags (-X main.version=...)
+var version = "dev"
+
 const (
-	joinTimeout = 15 * time.Second
+	joinTimeout  = 15 * time.Second
+	flushTimeout = 2 * time.Second // time for application to read last lines of worker output
 )
 
 type Config struct {
-	ConfigDir string `short:"c" long:"config-dir" env:"CONFIG_DIR" description:"Configuration directory (empty - default for OS)"`
-	Port      int    `short:"p" long:"port" env:"PORT" description:"Port for runner"`
-	Network   string `short:"n" long:"network" env:"NETWORK" description:"Network name for runner"`
+	ConfigDir  string          `short:"c" long:"config-dir" env:"CONFIG_DIR" description:"Configuration directory (empty - default for OS)"`
+	Port       int             `short:"p" long:"port" env:"PORT" description:"Port for runner"`
+	Network    string          `short:"n" long:"network" env:"NETWORK" description:"Network name for runner"`
+	Minimized  bool            `long:"minimized" hidden:"yes" description:"Ignored, accepted for login entries of previous versions"`
+	Debug      int             `long:"tincd-debug" env:"TINCD_DEBUG" description:"tincd debug level for runner (network settings by default)"`
+	KeepRoot   bool            `long:"keep-root" env:"KEEP_ROOT" description:"Do not drop root privileges of runner (Linux)"`
+	HelperPort int             `long:"helper-port" description:"Port for privileged helper which runs all networks"`
+	ParentPID  int             `long:"parent-pid" description:"Helper exits after exit of process with the PID"`
+	Netns      string          `long:"netns" env:"NETNS" description:"Move VPN interface of runner to Linux network namespace (created if needed, removed on exit), implies --keep-root"`
+	TokenFile  string          `long:"api-token-file" description:"Read auth token of runner API from file (file is removed)"`
+	Log        logging.Options `group:"Logging" namespace:"log" env-namespace:"LOG"`
+	Commands
 }
 
 func (cfg *Config) configure() error {
@@ -37,27 +58,92 @@ func (cfg *Config) configure() error {
 	return os.MkdirAll(cfg.ConfigDir, 0755)
 }
 
+// token is not passed by arguments because they are visible to other users
+func (cfg *Config) apiToken() (string, error) {
+	if cfg.TokenFile == "" {
+		return "", nil
+	}
+	token, err := workerstate.ReadToken(cfg.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("read API token: %w", err)
+	}
+	return token, nil
+}
+
 func (cfg *Config) logfile() string {
-	return filepath.Join(cfg.ConfigDir, "log.txt")
+	return logging.AppFile(cfg.ConfigDir)
+}
+
+// Open application log, or network log for worker (also written to extra writers). CLI commands log only to stderr.
+// Logger is always returned (stderr only in case of error)
+func (cfg *Config) openLog(command bool, extra ...io.Writer) (*logging.Logger, io.Closer, error) {
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	stderr := logging.New(os.Stderr, logging.Format(cfg.Log.Format), level)
+	if command {
+		return stderr, nil, nil
+	}
+	if cfg.Port != 0 {
+		logger, closer, err := cfg.Log.Open(logging.NetworkFile(cfg.ConfigDir, cfg.Network), append(extra, os.Stderr)...)
+		if err != nil {
+			return stderr, nil, err
+		}
+		return logger.With("network", cfg.Network), closer, nil
+	}
+	logger, closer, err := cfg.Log.Open(cfg.logfile(), os.Stderr)
+	if err != nil {
+		return stderr, nil, err
+	}
+	return logger, closer, nil
 }
 
 func main() {
 	var cfg Config
-	_, err := flags.Parse(&cfg)
+	parser := flags.NewParser(&cfg, flags.Default)
+	parser.SubcommandsOptional = true
+	parser.NamespaceDelimiter = "-"
+	_, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
+	if err := privileges.ExportInvokingUser(); err != nil {
+		log.Println("invoking user:", err)
+	}
 	err = cfg.configure()
 	if err != nil {
 		log.Fatal(err)
 	}
-	logfile, err := os.Create(cfg.logfile())
+	// configuration of namespace requires CAP_SYS_ADMIN
+	if parser.Active == nil && cfg.Port != 0 && !cfg.KeepRoot && cfg.Netns == "" {
+		// runner continues as invoking user with network capabilities only
+		if relaunched, err := dropPrivileges(&cfg); relaunched {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			} else if err != nil {
+				log.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	appSettings, settingsErr := settings.LoadApp(cfg.ConfigDir)
+	if level := parser.FindOptionByLongName("log-level"); level != nil && (!level.IsSet() || level.IsSetDefault()) {
+		cfg.Log.Level = appSettings.LogLevel
+	}
+	// worker output is shipped to application by API
+	output := &logging.Buffer{}
+	logger, logfile, err := cfg.openLog(parser.Active != nil || cfg.HelperPort != 0, output)
 	if err != nil {
-		log.Println(err)
-	} else {
-		log.SetOutput(io.MultiWriter(logfile, os.Stderr))
+		logger.Error("failed open log", "error", err)
+	}
+	if logfile != nil {
 		defer logfile.Close()
 	}
+	if settingsErr != nil {
+		logger.Warn("failed load settings, defaults used", "error", settingsErr)
+	}
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer(logging.LevelInfo))
 	gctx, closer := context.WithCancel(context.Background())
 	go func() {
 		c := make(chan os.Signal, 2)
@@ -71,46 +157,98 @@ func main() {
 
 	defer func() {
 		if r := recover(); r != nil {
-			log.Println(string(debug.Stack()))
+			logger.Error("panic", "reason", r, "stack", string(debug.Stack()))
 			if logfile != nil {
 				logfile.Close()
 			}
 			os.Exit(1)
 		}
 	}()
-	if cfg.Port == 0 {
-		err = run(gctx, cfg)
+	if parser.Active != nil {
+		err = runCommand(gctx, cfg, parser.Active.Name)
+	} else if cfg.HelperPort != 0 {
+		var token string
+		if token, err = cfg.apiToken(); err == nil {
+			err = runHelper(gctx, cfg, token, logger)
+		}
+	} else if cfg.Port == 0 {
+		err = run(gctx, cfg, logger, appSettings)
 	} else {
-		err = runNetwork(gctx, cfg.Port, filepath.Join(cfg.ConfigDir, cfg.Network))
+		var token string
+		if token, err = cfg.apiToken(); err == nil {
+			err = runNetwork(gctx, cfg.Port, token, filepath.Join(cfg.ConfigDir, cfg.Network), cfg.Debug, cfg.Netns, logger, output)
+		}
 	}
 	if err != nil {
-		log.Println(err)
+		logger.Error("failed", "error", err)
+	}
+	if cfg.Port != 0 {
+		output.Flush(flushTimeout)
+	}
+	if err != nil {
+		if logfile != nil {
+			logfile.Close()
+		}
+		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, cfg Config) error {
+func run(ctx context.Context, cfg Config, logger *logging.Logger, appSettings *settings.App) error {
+	guard, err := instance.Acquire(cfg.ConfigDir)
+	if errors.Is(err, instance.ErrRunning) {
+		logger.Info("application is already running, window of running instance is shown")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer guard.Close()
+	spawnerOptions := spawners.Options{ConfigLocation: cfg.ConfigDir, Logging: cfg.Log, Helper: appSettings.PrivilegeHelper}
+	spawner, err := spawners.New(appSettings.Spawner, spawnerOptions)
+	if err != nil {
+		logger.Warn("failed create spawner, automatic selection used", "error", err)
+		spawner, _ = spawners.New(spawners.Auto, spawnerOptions)
+	}
 	a := app.New()
 	w := a.NewWindow("Tinc desktop")
 	wapp := &App{
-		Window: w,
-		Ctx:    ctx,
-		Config: cfg,
-		App:    a,
-		Pool:   manager.Manager{Spawner: spawners.SelectSpawner(cfg.ConfigDir)},
+		Window:   w,
+		Ctx:      ctx,
+		Config:   cfg,
+		App:      a,
+		Pool:     manager.Manager{Spawner: spawner, StopTimeout: appSettings.StopDeadline()},
+		Settings: appSettings,
+		Logger:   logger,
+	}
+	wapp.applyTheme()
+	if appSettings.Autostart {
+		// executable could be moved since installation
+		if err := wapp.applyAutostart(); err != nil {
+			log.Println("autostart:", err)
+		}
 	}
 	w.Resize(fyne.NewSize(320, 480))
 	w.CenterOnScreen()
 	wapp.ShowMainScreen()
+	go wapp.autostartNetworks()
+	go wapp.checkUpdates()
 	go func() {
 		<-ctx.Done()
 		a.Quit()
 	}()
+	go guard.Serve(func() {
+		w.Show()
+		w.RequestFocus()
+	})
+	// there is no tray in the toolkit yet: without window minimized application could not be even closed, so
+	// window is shown anyway
 	w.ShowAndRun()
-	for _, name := range wapp.Pool.Names() {
-		if ntw := wapp.Pool.Find(name); ntw != nil {
-			log.Println("stopping", name)
-			_, _ = ntw.API().Kill(context.Background())
-			<-ntw.Done()
+	// stop is limited by stop timeout of manager: hung workers are killed
+	if err := wapp.Pool.StopAll(context.Background()); err != nil {
+		log.Println("stop networks:", err)
+	}
+	if helper, ok := wapp.Pool.Spawner.(io.Closer); ok {
+		if err := helper.Close(); err != nil {
+			log.Println("stop privileged helper:", err)
 		}
 	}
 	return nil
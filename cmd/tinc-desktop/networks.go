@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/theme"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+	"time"
+)
+
+const (
+	restartDelay    = 5 * time.Second
+	restartAttempts = 3 // in a row, without successful run at least restartDelay
+)
+
+var networkIcons = map[string]func() fyne.Resource{
+	"home":     theme.HomeIcon,
+	"folder":   theme.FolderIcon,
+	"mail":     theme.MailComposeIcon,
+	"settings": theme.SettingsIcon,
+	"help":     theme.HelpIcon,
+	"info":     theme.InfoIcon,
+}
+
+func networkIcon(ns *settings.Network) fyne.Resource {
+	if icon, ok := networkIcons[ns.Icon]; ok {
+		return icon()
+	}
+	return nil
+}
+
+// Start network worker, remember connection time and restart worker on failure if configured
+func (app *App) startNetwork(ntw *network.Network) (internal.Port, error) {
+	worker, err := app.Pool.Spawn(app.Ctx, ntw.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.UpdateNetwork(ntw, func(ns *settings.Network) {
+		ns.LastConnected = time.Now()
+	}); err != nil {
+		log.Println(ntw.Name(), "save settings:", err)
+	}
+	go app.watch(ntw, worker, 0)
+	return worker, nil
+}
+
+func (app *App) watch(ntw *network.Network, worker internal.Port, attempt int) {
+	started := time.Now()
+	<-worker.Done()
+	if worker.Error() == nil {
+		return
+	}
+	if state, _ := app.Pool.State(ntw.Name()); state == manager.Stopping || state == manager.Stopped {
+		// stopped by request
+		return
+	}
+	ns, err := settings.LoadNetwork(ntw)
+	if err != nil || ns.RestartPolicy != settings.RestartOnFailure {
+		return
+	}
+	if time.Since(started) > restartDelay {
+		attempt = 0
+	}
+	if attempt >= restartAttempts {
+		log.Println(ntw.Name(), "failed", attempt, "times in a row, no more restarts")
+		return
+	}
+	select {
+	case <-app.Ctx.Done():
+		return
+	case <-time.After(restartDelay):
+	}
+	log.Println("restarting", ntw.Name(), "after failure:", worker.Error())
+	next, err := app.Pool.Spawn(app.Ctx, ntw.Name())
+	if err != nil {
+		log.Println(ntw.Name(), "restart:", err)
+		return
+	}
+	app.watch(ntw, next, attempt+1)
+}
+
+// Stop network and tell user if it was not stopped gracefully
+func (app *App) stopNetwork(ctx context.Context, name string) error {
+	step, err := app.Pool.Stop(ctx, name)
+	if err != nil {
+		return err
+	}
+	var message string
+	switch step {
+	case internal.StepForced:
+		message = "tincd did not stop in time and was killed"
+	case internal.StepTerminated:
+		message = "worker did not exit and was terminated"
+	default:
+		return nil
+	}
+	log.Println(name, message)
+	dialog.NewInformation("Network "+name+" stopped", message, app.Window).Show()
+	return nil
+}
+
+// Adopt workers left running by previous instance of application and start networks marked for
+// automatic start (concurrently)
+func (app *App) autostartNetworks() {
+	networks, err := internal.Networks(app.Config.ConfigDir)
+	if err != nil {
+		log.Println("list networks:", err)
+		return
+	}
+	var names []string
+	for _, ntw := range networks {
+		names = append(names, ntw.Name())
+	}
+	app.Pool.Adopt(app.Ctx, names)
+	if !internal.CanStart() {
+		return
+	}
+	for _, ntw := range networks {
+		ns, err := settings.LoadNetwork(ntw)
+		if err != nil || !ns.Autostart {
+			continue
+		}
+		go func(ntw *network.Network) {
+			if _, err := app.startNetwork(ntw); err != nil {
+				log.Println("autostart", ntw.Name(), err)
+			}
+		}(ntw)
+	}
+}
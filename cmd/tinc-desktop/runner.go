@@ -2,47 +2,252 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"github.com/reddec/jsonrpc2"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/api"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/diag"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netns"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/privileges"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/workerstate"
 	"github.com/tinc-boot/tincd"
 	"github.com/tinc-boot/tincd/network"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"time"
 )
 
-func runNetwork(global context.Context, port int, directory string) error {
+var errNotStarted = errors.New("tincd is not started yet")
+
+func runNetwork(global context.Context, port int, token string, directory string, debugLevel int, namespace string, logger *logging.Logger, output *logging.Buffer) error {
 	ctx, cancel := context.WithCancel(global)
 	defer cancel()
 
-	inst, err := tincd.Start(ctx, &network.Network{Root: directory}, false)
+	var run = runner{output: output}
+
+	var router jsonrpc2.Router
+	api.RegisterWorker(&router, &run)
+
+	// API is available before tincd start to let application read reason of failure.
+	// Without port (foreground run) network is controlled by signals only
+	if port != 0 {
+		listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			return err
+		}
+		go func() {
+			wh := requireToken(token, jsonrpc2.HandlerRestContext(global, &router))
+			if err := http.Serve(listener, wh); err != nil {
+				logger.Error("failed serve API", "error", err)
+			}
+			cancel()
+		}()
+	}
+
+	lock, err := netlock.Acquire(directory)
 	if err != nil {
 		return err
 	}
+	defer lock.Release()
 
-	var run = runner{instance: inst}
+	if port != 0 {
+		// next instance of application finds worker if this one crashes
+		state := &workerstate.State{PID: os.Getpid(), Endpoint: "http://127.0.0.1:" + strconv.Itoa(port), Token: token, Started: time.Now()}
+		if err := workerstate.Write(directory, state); err != nil {
+			logger.Warn("failed save worker state", "error", err)
+		}
+		defer workerstate.Remove(directory)
+	}
 
-	var router jsonrpc2.Router
-	api.RegisterWorker(&router, &run)
+	ntw := &network.Network{Root: directory}
+	if debugLevel == 0 {
+		if ns, err := settings.LoadNetwork(ntw); err != nil {
+			logger.Warn("failed load network settings", "error", err)
+		} else {
+			debugLevel = ns.DebugLevel
+		}
+	}
 
+	if namespace != "" {
+		if err := netns.Ensure(namespace); err != nil {
+			return err
+		}
+		defer func() {
+			if err := netns.Remove(namespace); err != nil {
+				logger.Warn("failed remove namespace", "namespace", namespace, "error", err)
+			}
+		}()
+		// tinc-up configures interface in namespace
+		restore, err := netns.Intercept(namespace)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	// output of previous run should not be forwarded again
+	_ = os.Remove(logging.TincdFile(directory))
+	inst, err := internal.StartTincd(ctx, ntw)
+	if err != nil {
+		return err
+	}
+	run.setInstance(inst)
+	if err := run.debug.Set(ctx, debugLevel); err != nil {
+		inst.Stop()
+		<-inst.Done()
+		return err
+	}
+	if namespace != "" {
+		go func() {
+			if err := waitNamespace(ctx, ntw, namespace); err != nil {
+				// traffic must not leak outside of namespace
+				logger.Error("failed configure interface in namespace", "namespace", namespace, "error", err)
+				cancel()
+			}
+		}()
+	}
+	followed := make(chan struct{})
 	go func() {
-		wh := jsonrpc2.HandlerRestContext(ctx, &router)
-		_ = http.ListenAndServe("127.0.0.1:"+strconv.Itoa(port), wh)
-		cancel()
+		defer close(followed)
+		// debug output of tincd is always shipped to application even if it is not written to log file
+		logging.FollowTincd(ctx, directory, logger.With("component", "tincd"), logger.Fork(output, logging.LevelDebug).With("component", "tincd"))
 	}()
 
 	<-inst.Done()
+	cancel()
+	<-followed
 	return inst.Error()
 }
 
+// API requests without auth token of worker are rejected
+func requireToken(token string, handler http.Handler) http.Handler {
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		actual := request.Header.Get(workerstate.TokenHeader)
+		if subtle.ConstantTimeCompare([]byte(actual), []byte(token)) != 1 {
+			http.Error(writer, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func waitNamespace(ctx context.Context, ntw *network.Network, namespace string) error {
+	config, err := ntw.Read()
+	if err != nil {
+		return err
+	}
+	iface := config.Interface
+	if iface == "" {
+		iface = ntw.Name()
+	}
+	return netns.WaitInterface(ctx, namespace, iface)
+}
+
+// Relaunch runner as invoking user with network capabilities only. Files left by previous privileged runs
+// are handed over to the user
+func dropPrivileges(cfg *Config) (bool, error) {
+	ntw := &network.Network{Root: filepath.Join(cfg.ConfigDir, cfg.Network)}
+	privilegedPort := true // default tinc port is 655
+	if config, err := ntw.Read(); err == nil && config.Port >= 1024 {
+		privilegedPort = false
+	}
+	return privileges.Relaunch(privileges.Capabilities(privilegedPort), func(uid, gid int) error {
+		for _, file := range []string{ntw.Pidfile(), logging.TincdFile(ntw.Root), logging.NetworkFile(cfg.ConfigDir, cfg.Network)} {
+			if err := os.Lchown(file, uid, gid); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 type runner struct {
+	output   *logging.Buffer
+	lock     sync.Mutex
 	instance tincd.Tincd
+	debug    *internal.DebugLevel
+}
+
+func (r *runner) setInstance(instance tincd.Tincd) {
+	r.lock.Lock()
+	r.instance = instance
+	r.debug = internal.NewDebugLevel(instance.Definition().Pidfile())
+	r.lock.Unlock()
+}
+
+func (r *runner) tincd() (tincd.Tincd, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.instance == nil {
+		return nil, errNotStarted
+	}
+	return r.instance, nil
 }
 
 func (r *runner) Kill(ctx context.Context) (bool, error) {
-	r.instance.Stop()
-	return true, r.instance.Error()
+	inst, err := r.tincd()
+	if err != nil {
+		return false, err
+	}
+	inst.Stop()
+	return true, inst.Error()
+}
+
+func (r *runner) Stop(ctx context.Context, timeout time.Duration) (internal.StopStep, error) {
+	inst, err := r.tincd()
+	if err != nil {
+		return "", err
+	}
+	return internal.StopTincd(ctx, inst, timeout)
 }
 
 func (r *runner) Peers(ctx context.Context) ([]string, error) {
-	return r.instance.Peers(), r.instance.Error()
+	inst, err := r.tincd()
+	if err != nil {
+		return nil, err
+	}
+	return inst.Peers(), inst.Error()
+}
+
+func (r *runner) Reload(ctx context.Context) (bool, error) {
+	inst, err := r.tincd()
+	if err != nil {
+		return false, err
+	}
+	err = internal.Reload(inst.Definition().Pidfile())
+	return err == nil, err
+}
+
+func (r *runner) Diagnose(ctx context.Context, node string) (*internal.Diagnostics, error) {
+	inst, err := r.tincd()
+	if err != nil {
+		return nil, err
+	}
+	return diag.Node(ctx, inst.Definition(), node, diag.DefaultCount)
+}
+
+func (r *runner) Logs(ctx context.Context, after uint64) ([]internal.LogLine, error) {
+	return internal.ReadLogs(ctx, r.output, after), nil
+}
+
+func (r *runner) SetDebugLevel(ctx context.Context, level int) (bool, error) {
+	if _, err := r.tincd(); err != nil {
+		return false, err
+	}
+	r.lock.Lock()
+	debug := r.debug
+	r.lock.Unlock()
+	err := debug.Set(ctx, level)
+	return err == nil, err
 }
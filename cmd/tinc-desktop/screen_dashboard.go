@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/pkg/browser"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dashboardRefresh = 5 * time.Second
+	peersTimeout     = 2 * time.Second
+)
+
+const (
+	sortByName          = "name"
+	sortByStatus        = "status"
+	sortByLastConnected = "last connected"
+)
+
+// order of states in sorting by status
+var stateOrder = map[manager.State]int{
+	manager.Running:     0,
+	manager.Degraded:    1,
+	manager.Authorizing: 2,
+	manager.Starting:    3,
+	manager.Stopping:    4,
+	manager.Failed:      5,
+	manager.Stopped:     6,
+}
+
+type dashboardRow struct {
+	Network *network.Network
+	Meta    *settings.Network
+	State   manager.State
+	IP      string
+	Worker  internal.Port
+	Error   error
+	Service *serviceState
+}
+
+type screenDashboard struct {
+	Window fyne.Window
+	Ctx    context.Context
+	App    *App
+
+	lock     sync.Mutex
+	search   string
+	sortBy   string
+	peers    map[string]int
+	services map[string]*serviceState // queried from service manager only by timer: it is slow
+	list     *widget.Box
+	stop     func()
+}
+
+func (sd *screenDashboard) Show() {
+	sd.Window.SetTitle("Tinc desktop")
+	sd.sortBy = sortByName
+	sd.peers = make(map[string]int)
+	sd.services = make(map[string]*serviceState)
+	sd.list = widget.NewVBox()
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("search...")
+	search.OnChanged = func(s string) {
+		sd.lock.Lock()
+		sd.search = strings.ToLower(strings.TrimSpace(s))
+		sd.lock.Unlock()
+		sd.render()
+	}
+	sortBy := widget.NewSelect([]string{sortByName, sortByStatus, sortByLastConnected}, func(s string) {
+		sd.lock.Lock()
+		sd.sortBy = s
+		sd.lock.Unlock()
+		sd.render()
+	})
+	sortBy.SetSelected(sd.sortBy)
+
+	top := widget.NewVBox(
+		widget.NewToolbar(
+			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+				sd.leave(sd.App.App.Quit)
+			}),
+			widget.NewToolbarSeparator(),
+			widget.NewToolbarSpacer(),
+			widget.NewToolbarAction(theme.FolderOpenIcon(), func() {
+				err := browser.OpenFile(sd.App.Config.ConfigDir)
+				if err != nil {
+					dialog.NewInformation("Failed open config dir", err.Error(), sd.Window).Show()
+				}
+			}),
+			widget.NewToolbarAction(theme.InfoIcon(), func() {
+				sd.leave(func() { sd.App.ShowLogsScreen(logsSourceAll, sd.App.ShowMainScreen) })
+			}),
+			widget.NewToolbarAction(theme.HelpIcon(), func() {
+				sd.App.createSupportBundle()
+			}),
+			widget.NewToolbarAction(theme.SettingsIcon(), func() {
+				sd.leave(sd.App.ShowSettingsScreen)
+			}),
+			widget.NewToolbarAction(theme.MoveDownIcon(), func() {
+				sd.leave(sd.App.ShowJoinByURLScreen)
+			}),
+			widget.NewToolbarAction(theme.ContentAddIcon(), func() {
+				sd.leave(sd.App.ShowNewNetworkScreen)
+			}),
+		),
+		fyne.NewContainerWithLayout(layout.NewGridLayout(2), search, sortBy),
+	)
+
+	sd.Window.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, nil, nil),
+		top,
+		widget.NewVScrollContainer(sd.list),
+	))
+
+	ctx, cancel := context.WithCancel(sd.Ctx)
+	events, unsubscribe := sd.App.Pool.Subscribe()
+	sd.stop = func() {
+		cancel()
+		unsubscribe()
+	}
+	sd.render()
+	go sd.refresh(ctx, events)
+}
+
+// stop auto refresh and go to another screen
+func (sd *screenDashboard) leave(next func()) {
+	sd.stop()
+	next()
+}
+
+func (sd *screenDashboard) refresh(ctx context.Context, events <-chan manager.Event) {
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	sd.updateServices()
+	sd.updatePeers(ctx)
+	sd.render()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		case <-ticker.C:
+			sd.updateServices()
+		}
+		sd.updatePeers(ctx)
+		sd.render()
+	}
+}
+
+func (sd *screenDashboard) updateServices() {
+	networks, err := internal.Networks(sd.App.Config.ConfigDir)
+	if err != nil {
+		log.Println("failed list networks:", err)
+	}
+	var states = make(map[string]*serviceState)
+	for _, ntw := range networks {
+		states[ntw.Name()] = sd.App.serviceState(ntw)
+	}
+	sd.lock.Lock()
+	sd.services = states
+	sd.lock.Unlock()
+}
+
+func (sd *screenDashboard) updatePeers(ctx context.Context) {
+	var counts = make(map[string]int)
+	for _, name := range sd.App.Pool.Names() {
+		worker := sd.App.Pool.Find(name)
+		if worker == nil {
+			continue
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, peersTimeout)
+		peers, err := worker.API().Peers(reqCtx)
+		cancel()
+		if err == nil {
+			counts[name] = len(peers)
+		}
+	}
+	sd.lock.Lock()
+	sd.peers = counts
+	sd.lock.Unlock()
+}
+
+func (sd *screenDashboard) rows() []*dashboardRow {
+	networks, err := internal.Networks(sd.App.Config.ConfigDir)
+	if err != nil {
+		log.Println("failed list networks:", err)
+	}
+	var ans []*dashboardRow
+	for _, ntw := range networks {
+		row := &dashboardRow{Network: ntw}
+		meta, err := settings.LoadNetwork(ntw)
+		if err != nil {
+			log.Println(ntw.Name(), "load settings:", err)
+			meta = settings.DefaultNetwork()
+		}
+		row.Meta = meta
+		sd.lock.Lock()
+		row.Service = sd.services[ntw.Name()]
+		sd.lock.Unlock()
+		if row.Service == nil {
+			row.Service = &serviceState{}
+		}
+		if self, err := ntw.Self(); err == nil {
+			row.IP = self.IP
+		}
+		state, err := sd.App.Pool.State(ntw.Name())
+		row.State = state
+		if state == manager.Failed {
+			row.Error = err
+		}
+		if state.Active() {
+			row.Worker = sd.App.Pool.Find(ntw.Name())
+		}
+		ans = append(ans, row)
+	}
+	return ans
+}
+
+func (sd *screenDashboard) render() {
+	rows := sd.rows()
+	sd.lock.Lock()
+	search, sortBy := sd.search, sd.sortBy
+	peers := sd.peers
+	sd.lock.Unlock()
+
+	var filtered []*dashboardRow
+	for _, row := range rows {
+		if search == "" || row.matches(search) {
+			filtered = append(filtered, row)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		switch sortBy {
+		case sortByStatus:
+			if a.State != b.State {
+				return stateOrder[a.State] < stateOrder[b.State]
+			}
+		case sortByLastConnected:
+			if !a.Meta.LastConnected.Equal(b.Meta.LastConnected) {
+				return a.Meta.LastConnected.After(b.Meta.LastConnected)
+			}
+		}
+		return strings.ToLower(a.Meta.Title(a.Network)) < strings.ToLower(b.Meta.Title(b.Network))
+	})
+
+	var items []fyne.CanvasObject
+	for _, row := range filtered {
+		items = append(items, sd.renderRow(row, peers))
+	}
+	if len(items) == 0 {
+		items = append(items, widget.NewLabel("no networks"))
+	}
+	sd.list.Children = items
+	sd.list.Refresh()
+}
+
+func (sd *screenDashboard) renderRow(row *dashboardRow, peers map[string]int) fyne.CanvasObject {
+	mark := canvas.NewRectangle(row.Meta.RGBA())
+	mark.SetMinSize(fyne.NewSize(theme.Padding()*2, 0))
+
+	title := row.Meta.Title(row.Network)
+	if len(row.Meta.Tags) > 0 {
+		title += " [" + strings.Join(row.Meta.Tags, ", ") + "]"
+	}
+	link := widget.NewButtonWithIcon(title, networkIcon(row.Meta), func() {
+		sd.leave(func() { sd.App.ShowNetworkScreen(row.Network) })
+	})
+
+	info := []string{string(row.State), row.IP}
+	if row.Service.Installed() {
+		info = append(info, "managed by "+row.Service.String())
+	}
+	if count, ok := peers[row.Network.Name()]; ok && (row.State == manager.Running || row.State == manager.Degraded) {
+		info = append(info, strconv.Itoa(count)+" peers")
+	}
+	details := widget.NewVBox(link, widget.NewLabel(strings.Join(info, " · ")))
+	if row.Error != nil {
+		text := row.Error.Error()
+		if len(text) > 80 {
+			text = text[:80] + "..."
+		}
+		details.Append(widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+	}
+
+	var action *widget.Button
+	if row.Worker != nil {
+		name := row.Network.Name()
+		action = widget.NewButtonWithIcon("", theme.MediaPauseIcon(), func() {
+			log.Println("stop", name)
+			go func() {
+				if err := sd.App.stopNetwork(sd.App.Ctx, name); err != nil {
+					log.Println("stop", name, err)
+				}
+			}()
+		})
+	} else {
+		action = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), func() {
+			sd.start(row.Network)
+		})
+		if row.Service.Installed() {
+			// network device is owned by system service
+			action.Disable()
+		}
+	}
+	return fyne.NewContainerWithLayout(layout.NewBorderLayout(nil, nil, mark, action), mark, action, details)
+}
+
+func (sd *screenDashboard) start(ntw *network.Network) {
+	if !internal.CanStart() {
+		dialog.NewInformation("Oops", "Please start application as Administrator", sd.Window).Show()
+		return
+	}
+	progress := dialog.NewProgressInfinite("Starting", "starting "+ntw.Name()+"...", sd.Window)
+	progress.Show()
+	_, err := sd.App.startNetwork(ntw)
+	progress.Hide()
+	if err != nil {
+		log.Println("start", ntw.Name(), err)
+		dialog.NewInformation("Failed to start", err.Error(), sd.Window).Show()
+	}
+}
+
+func (row *dashboardRow) matches(search string) bool {
+	texts := append([]string{row.Network.Name(), row.Meta.DisplayName, row.Meta.Description, row.IP}, row.Meta.Tags...)
+	for _, text := range texts {
+		if strings.Contains(strings.ToLower(text), search) {
+			return true
+		}
+	}
+	return false
+}
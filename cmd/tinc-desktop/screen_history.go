@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/history"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+)
+
+type screenHistory struct {
+	Window  fyne.Window
+	Network *network.Network
+	Ctx     context.Context
+	App     *App
+}
+
+func (sh *screenHistory) Show() {
+	sh.Window.SetTitle(sh.Network.Name() + " history")
+
+	snapshots, err := history.Of(sh.Network).List()
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sh.Window).Show()
+		return
+	}
+
+	var items []fyne.CanvasObject
+	for _, snap := range snapshots {
+		var cp = snap
+		items = append(items, widget.NewHBox(
+			widget.NewLabel(cp.Created.Format("2006-01-02 15:04:05")+" "+cp.Reason),
+			layout.NewSpacer(),
+			widget.NewButtonWithIcon("changes", theme.SearchIcon(), func() {
+				sh.showChanges(cp)
+			}),
+			widget.NewButtonWithIcon("restore", theme.ContentUndoIcon(), func() {
+				sh.rollback(cp)
+			}),
+		))
+	}
+	if len(items) == 0 {
+		items = append(items, widget.NewLabel("no changes recorded yet"))
+	}
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+			sh.App.ShowNetworkScreen(sh.Network)
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarSpacer(),
+	)
+
+	sh.Window.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(toolbar, nil, nil, nil),
+		toolbar,
+		widget.NewVScrollContainer(widget.NewVBox(items...)),
+	))
+}
+
+func (sh *screenHistory) showChanges(snap *history.Snapshot) {
+	previous, err := history.Of(sh.Network).Previous(snap.ID)
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sh.Window).Show()
+		return
+	}
+	text := history.Diff(previous, snap)
+	if text == "" {
+		text = "no changes"
+	}
+	content := widget.NewVScrollContainer(widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
+	dialog.ShowCustom("Changes", "Close", fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.NewSize(400, 300)), content), sh.Window)
+}
+
+func (sh *screenHistory) rollback(snap *history.Snapshot) {
+	dialog.ShowConfirm("Rollback", "Restore configuration from "+snap.Created.Format("2006-01-02 15:04:05")+"?", func(ok bool) {
+		if !ok {
+			return
+		}
+		progress := dialog.NewProgressInfinite("Restoring", "restoring... ", sh.Window)
+		progress.Show()
+
+		self, config, err := sh.Network.SelfConfig()
+		if err == nil {
+			err = history.Of(sh.Network).Rollback(snap.ID)
+		}
+		if err != nil {
+			progress.Hide()
+			log.Println("rollback", sh.Network.Name(), err)
+			dialog.NewInformation("Failed", err.Error(), sh.Window).Show()
+			return
+		}
+		restoredSelf, restoredConfig, err := sh.Network.SelfConfig()
+		progress.Hide()
+		if err != nil {
+			dialog.NewInformation("Failed", err.Error(), sh.Window).Show()
+			return
+		}
+		mode := classifyUpgrade(self, config, network.Upgrade{
+			Port:    restoredConfig.Port,
+			Address: restoredSelf.Address,
+			Device:  restoredConfig.Device,
+		})
+		if mode == applyNone {
+			// hosts are changed anyway
+			mode = applyReload
+		}
+		sh.App.ApplyToRunning(sh.Network, mode)
+	}, sh.Window)
+}
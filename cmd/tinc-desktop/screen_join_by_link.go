@@ -4,15 +4,20 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fyne.io/fyne"
 	"fyne.io/fyne/dialog"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
 	"github.com/tinc-boot/tinc-desktop/api/tincwebmajordomo"
-	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/history"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tincd/network"
 	"log"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type screenJoinByLink struct {
@@ -24,6 +29,10 @@ type screenJoinByLink struct {
 func (sjl *screenJoinByLink) Show() {
 	url := widget.NewEntry()
 	url.PlaceHolder = "URL"
+	servers := widget.NewSelect(sjl.App.Settings.MajordomoServers, func(server string) {
+		url.SetText(server + "/")
+	})
+	servers.PlaceHolder = "known servers"
 	sjl.Window.SetContent(widget.NewVBox(
 		widget.NewToolbar(
 			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
@@ -32,6 +41,7 @@ func (sjl *screenJoinByLink) Show() {
 			widget.NewToolbarSeparator(),
 			widget.NewToolbarSpacer(),
 		),
+		servers,
 		widget.NewHScrollContainer(url),
 		widget.NewButton("Join", func() {
 			sjl.join(strings.TrimSpace(url.Text))
@@ -40,66 +50,92 @@ func (sjl *screenJoinByLink) Show() {
 }
 
 func (sjl *screenJoinByLink) join(url string) {
-	parts := strings.Split(url, "/")
-	token := parts[len(parts)-1]
-
-	if len(token) == 0 || !strings.Contains(token, ".") {
+	share, err := decodeJoinURL(url)
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
 		return
 	}
 
-	data := strings.Split(token, ".")[1]
-	bindata, err := base64.RawStdEncoding.DecodeString(data)
+	progress := dialog.NewProgressInfinite("Creating", "creating "+share.Network+" network", sjl.Window)
+	progress.Show()
+
+	ctx, cancel := context.WithTimeout(context.Background(), joinTimeout)
+	defer cancel()
+	ntw, err := sjl.App.joinNetwork(ctx, url)
+	progress.Hide()
 	if err != nil {
 		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
 		return
 	}
 
-	var share struct {
-		Network string `json:"network"`
-		Subnet  string `json:"subnet"`
-	}
+	sjl.App.ShowNetworkScreen(ntw)
+}
 
-	err = json.Unmarshal(bindata, &share)
+// Create network by join URL and exchange host files with majordomo server. Server is remembered in settings
+func (app *App) joinNetwork(ctx context.Context, url string) (*network.Network, error) {
+	share, err := decodeJoinURL(url)
 	if err != nil {
-		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
-		return
+		return nil, err
 	}
 
 	remote := &tincwebmajordomo.TincWebMajordomoClient{BaseURL: url}
 
-	progress := dialog.NewProgressInfinite("Creating", "creating "+share.Network+" network", sjl.Window)
-	progress.Show()
-
-	ntw, err := tincd.Create(filepath.Join(sjl.App.Config.ConfigDir, share.Network), share.Subnet)
+	ntw, err := netlock.Create(filepath.Join(app.Config.ConfigDir, share.Network), share.Subnet)
 	if err != nil {
-		progress.Hide()
-		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
-		return
+		return nil, err
 	}
 
 	self, err := ntw.Self()
 	if err != nil {
-		progress.Hide()
-		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
-		return
+		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), joinTimeout)
-	defer cancel()
 	sharedNet, err := remote.Join(ctx, share.Network, self)
 	if err != nil {
-		progress.Hide()
-		dialog.NewInformation("Failed", err.Error(), sjl.Window).Show()
-		return
+		return nil, err
 	}
 
-	for _, node := range sharedNet.Nodes {
-		err = ntw.Put(node)
-		if err != nil {
-			log.Println(node.Name, err)
+	if err := history.Of(ntw).Save("created"); err != nil {
+		log.Println("save history:", err)
+	}
+	if err := settings.UpdateNetwork(ntw, func(ns *settings.Network) {
+		ns.Created = time.Now()
+		ns.JoinURL = url
+	}); err != nil {
+		log.Println("save network settings:", err)
+	}
+	_, err = importNodes(ntw, sharedNet.Nodes, "joined "+share.Network)
+	if err != nil {
+		log.Println("import nodes:", err)
+	}
+	if idx := strings.LastIndex(url, "/"); idx > 0 && app.Settings.AddMajordomo(url[:idx]) {
+		if err := app.Settings.Save(app.Config.ConfigDir); err != nil {
+			log.Println("save settings:", err)
 		}
 	}
-	progress.Hide()
+	return ntw, nil
+}
 
-	sjl.App.ShowNetworkScreen(ntw)
+type joinShare struct {
+	Network string `json:"network"`
+	Subnet  string `json:"subnet"`
+}
+
+// decode network name and subnet from join URL (last part of path is JWT-like token)
+func decodeJoinURL(url string) (*joinShare, error) {
+	parts := strings.Split(url, "/")
+	token := parts[len(parts)-1]
+
+	if len(token) == 0 || !strings.Contains(token, ".") {
+		return nil, errors.New("invalid join URL")
+	}
+
+	data := strings.Split(token, ".")[1]
+	bindata, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var share joinShare
+	return &share, json.Unmarshal(bindata, &share)
 }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logsSourceAll = "all"
+	logsSourceApp = "application"
+	logsMaxLines  = 1000
+	logsRefresh   = 500 * time.Millisecond
+)
+
+type logEntry struct {
+	Source string
+	Record logging.Record
+}
+
+type screenLogs struct {
+	Window fyne.Window
+	Ctx    context.Context
+	App    *App
+	Source string // logsSourceAll, logsSourceApp or network name
+	Back   func()
+
+	lock    sync.Mutex
+	entries []logEntry
+	dirty   bool
+	level   logging.Level
+	filter  string
+	stop    func()
+	text    *widget.Label
+	scroll  *widget.ScrollContainer
+}
+
+func (sl *screenLogs) Show() {
+	sl.Window.SetTitle("Logs")
+	if sl.Source == "" {
+		sl.Source = logsSourceAll
+	}
+	sl.level = logging.LevelDebug
+
+	sources := []string{logsSourceAll, logsSourceApp}
+	networks, _ := internal.Networks(sl.App.Config.ConfigDir)
+	for _, ntw := range networks {
+		sources = append(sources, ntw.Name())
+	}
+
+	sourceSelect := widget.NewSelect(sources, func(value string) {
+		sl.follow(value)
+	})
+	levelSelect := widget.NewSelect([]string{"debug", "info", "warn", "error"}, func(value string) {
+		level, _ := logging.ParseLevel(value)
+		sl.lock.Lock()
+		sl.level = level
+		sl.dirty = true
+		sl.lock.Unlock()
+	})
+	levelSelect.SetSelected(sl.level.String())
+	search := widget.NewEntry()
+	search.SetPlaceHolder("filter...")
+	search.OnChanged = func(value string) {
+		sl.lock.Lock()
+		sl.filter = strings.ToLower(value)
+		sl.dirty = true
+		sl.lock.Unlock()
+	}
+
+	sl.text = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	sl.scroll = widget.NewVScrollContainer(sl.text)
+
+	ctx, cancel := context.WithCancel(sl.Ctx)
+	toolbar := widget.NewVBox(
+		widget.NewToolbar(
+			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+				cancel()
+				sl.stopFollow()
+				sl.Back()
+			}),
+			widget.NewToolbarSeparator(),
+			widget.NewToolbarSpacer(),
+			widget.NewToolbarAction(theme.ContentCopyIcon(), func() {
+				sl.Window.Clipboard().SetContent(sl.selection())
+			}),
+			widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+				sl.save()
+			}),
+		),
+		fyne.NewContainerWithLayout(layout.NewGridLayout(3), sourceSelect, levelSelect, search),
+	)
+
+	sl.Window.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(toolbar, nil, nil, nil),
+		toolbar,
+		sl.scroll,
+	))
+	sourceSelect.SetSelected(sl.Source)
+	go sl.refresher(ctx)
+}
+
+// restart followers for the new source
+func (sl *screenLogs) follow(source string) {
+	sl.stopFollow()
+	sl.lock.Lock()
+	sl.Source = source
+	sl.entries = nil
+	sl.dirty = true
+	sl.lock.Unlock()
+
+	files := map[string]string{}
+	if source == logsSourceAll || source == logsSourceApp {
+		files[logsSourceApp] = logging.AppFile(sl.App.Config.ConfigDir)
+	}
+	if source == logsSourceAll {
+		networks, _ := internal.Networks(sl.App.Config.ConfigDir)
+		for _, ntw := range networks {
+			files[ntw.Name()] = logging.NetworkFile(sl.App.Config.ConfigDir, ntw.Name())
+		}
+	} else if source != logsSourceApp {
+		files[source] = logging.NetworkFile(sl.App.Config.ConfigDir, source)
+	}
+
+	ctx, cancel := context.WithCancel(sl.Ctx)
+	var wg sync.WaitGroup
+	for name, file := range files {
+		wg.Add(1)
+		go func(name, file string) {
+			defer wg.Done()
+			logging.Follow(ctx, file, func(line string) {
+				sl.add(logEntry{Source: name, Record: logging.ParseRecord(line)})
+			})
+		}(name, file)
+	}
+	sl.lock.Lock()
+	sl.stop = func() {
+		cancel()
+		wg.Wait()
+	}
+	sl.lock.Unlock()
+}
+
+func (sl *screenLogs) stopFollow() {
+	sl.lock.Lock()
+	stop := sl.stop
+	sl.stop = nil
+	sl.lock.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+func (sl *screenLogs) add(entry logEntry) {
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	sl.entries = append(sl.entries, entry)
+	if len(sl.entries) > logsMaxLines {
+		sl.entries = sl.entries[len(sl.entries)-logsMaxLines:]
+	}
+	sl.dirty = true
+}
+
+// re-render text not often than logsRefresh to keep UI responsive on noisy logs
+func (sl *screenLogs) refresher(ctx context.Context) {
+	ticker := time.NewTicker(logsRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sl.lock.Lock()
+		dirty := sl.dirty
+		sl.dirty = false
+		sl.lock.Unlock()
+		if !dirty {
+			continue
+		}
+		sl.text.SetText(sl.selection())
+		sl.scroll.Offset.Y = sl.text.MinSize().Height
+		sl.scroll.Refresh()
+	}
+}
+
+// filtered lines as text
+func (sl *screenLogs) selection() string {
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	var lines []string
+	for _, entry := range sl.entries {
+		if entry.Record.Level < sl.level {
+			continue
+		}
+		if sl.filter != "" && !strings.Contains(strings.ToLower(entry.Record.Raw), sl.filter) && !strings.Contains(strings.ToLower(entry.Source), sl.filter) {
+			continue
+		}
+		lines = append(lines, formatLogEntry(entry))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fyne has no file dialogs yet, so selection is saved next to log files
+func (sl *screenLogs) save() {
+	file := filepath.Join(filepath.Dir(logging.AppFile(sl.App.Config.ConfigDir)), "export-"+time.Now().Format("20060102T150405")+".log")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		dialog.ShowError(err, sl.Window)
+		return
+	}
+	if err := ioutil.WriteFile(file, []byte(sl.selection()+"\n"), 0644); err != nil {
+		dialog.ShowError(err, sl.Window)
+		return
+	}
+	dialog.ShowInformation("Saved", file, sl.Window)
+}
+
+func formatLogEntry(entry logEntry) string {
+	ts := entry.Record.Time
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		ts = t.Format("01-02 15:04:05")
+	}
+	return strings.TrimSpace(ts + " " + strings.ToUpper(entry.Record.Level.String()) + " [" + entry.Source + "] " + entry.Record.Message + " " + entry.Record.Extra())
+}
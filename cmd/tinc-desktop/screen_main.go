@@ -4,72 +4,86 @@ import (
 	"context"
 	"fyne.io/fyne"
 	"fyne.io/fyne/dialog"
-	"fyne.io/fyne/theme"
-	"fyne.io/fyne/widget"
-	"github.com/pkg/browser"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/support"
 	"github.com/tinc-boot/tincd/network"
 	"log"
+	"sync"
 )
 
 type App struct {
-	Window fyne.Window
-	Ctx    context.Context
-	Config Config
-	App    fyne.App
-	Pool   manager.Manager
+	Window   fyne.Window
+	Ctx      context.Context
+	Config   Config
+	App      fyne.App
+	Pool     manager.Manager
+	Settings *settings.App
+	Logger   *logging.Logger
+
+	screenLock  sync.Mutex
+	closeScreen func()
 }
 
-func (app *App) ShowMainScreen() {
-	app.Window.SetTitle("Tinc desktop")
-	networks, err := network.List(app.Config.ConfigDir)
-	if err != nil {
-		log.Println("failed list networks:", err)
+// Context of new screen. Context of previous screen is cancelled, so its background jobs and subscriptions stop
+func (app *App) screenContext() context.Context {
+	ctx, cancel := context.WithCancel(app.Ctx)
+	app.screenLock.Lock()
+	defer app.screenLock.Unlock()
+	if app.closeScreen != nil {
+		app.closeScreen()
 	}
+	app.closeScreen = cancel
+	return ctx
+}
+
+// Call handler on state changes of networks till context done
+func (app *App) watchStates(ctx context.Context, handler func(event manager.Event)) {
+	events, unsubscribe := app.Pool.Subscribe()
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				handler(event)
+			}
+		}
+	}()
+}
 
-	var links []fyne.CanvasObject
-	for _, ntw := range networks {
-		var cp = ntw
-		links = append(links, widget.NewButton(ntw.Name(), func() {
-			app.ShowNetworkScreen(cp)
-		}))
+func (app *App) ShowMainScreen() {
+	screen := &screenDashboard{
+		Window: app.Window,
+		Ctx:    app.screenContext(),
+		App:    app,
 	}
+	screen.Show()
+}
 
-	app.Window.SetContent(widget.NewVBox(
-		widget.NewToolbar(
-			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
-				app.App.Quit()
-			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarSpacer(),
-			widget.NewToolbarAction(theme.FolderOpenIcon(), func() {
-				err := browser.OpenFile(app.Config.ConfigDir)
-				if err != nil {
-					dialog.NewInformation("Failed open config dir", err.Error(), app.Window).Show()
-				}
-			}),
-			widget.NewToolbarAction(theme.InfoIcon(), func() {
-				err := browser.OpenFile(app.Config.logfile())
-				if err != nil {
-					dialog.NewInformation("Failed open log file", err.Error(), app.Window).Show()
-				}
-			}),
-			widget.NewToolbarAction(theme.MoveDownIcon(), func() {
-				app.ShowJoinByURLScreen()
-			}),
-			widget.NewToolbarAction(theme.ContentAddIcon(), func() {
-				app.ShowNewNetworkScreen()
-			}),
-		),
-		widget.NewVBox(links...),
-	))
+func (app *App) createSupportBundle() {
+	progress := dialog.NewProgressInfinite("Support bundle", "collecting logs and configuration...", app.Window)
+	progress.Show()
+	bundle := &support.Bundle{ConfigDir: app.Config.ConfigDir, Version: version, Workers: &app.Pool}
+	file := bundle.DefaultFile()
+	err := bundle.Save(app.Ctx, file)
+	progress.Hide()
+	if err != nil {
+		log.Println("create support bundle:", err)
+		dialog.NewInformation("Failed create support bundle", err.Error(), app.Window).Show()
+		return
+	}
+	app.Window.Clipboard().SetContent(file)
+	dialog.NewInformation("Support bundle", "Saved to (path copied to clipboard)\n"+file+"\nPrivate keys are not included", app.Window).Show()
 }
 
 func (app *App) ShowNetworkScreen(ntw *network.Network) {
 	screen := &screenNetwork{
 		Window:  app.Window,
 		Network: ntw,
-		Ctx:     app.Ctx,
+		Ctx:     app.screenContext(),
 		App:     app,
 	}
 	screen.Show()
@@ -79,16 +93,56 @@ func (app *App) ShowNetworkSettingsScreen(ntw *network.Network) {
 	screen := &screenSettingsNetwork{
 		Window:  app.Window,
 		Network: ntw,
-		Ctx:     app.Ctx,
+		Ctx:     app.screenContext(),
+		App:     app,
+	}
+	screen.Show()
+}
+
+func (app *App) ShowHistoryScreen(ntw *network.Network) {
+	screen := &screenHistory{
+		Window:  app.Window,
+		Network: ntw,
+		Ctx:     app.screenContext(),
 		App:     app,
 	}
 	screen.Show()
 }
 
+func (app *App) ShowPeersScreen(ntw *network.Network) {
+	screen := &screenPeers{
+		Window:  app.Window,
+		Network: ntw,
+		Ctx:     app.screenContext(),
+		App:     app,
+	}
+	screen.Show()
+}
+
+func (app *App) ShowLogsScreen(source string, back func()) {
+	screen := &screenLogs{
+		Window: app.Window,
+		Ctx:    app.screenContext(),
+		App:    app,
+		Source: source,
+		Back:   back,
+	}
+	screen.Show()
+}
+
+func (app *App) ShowSettingsScreen() {
+	screen := &screenSettings{
+		Window: app.Window,
+		Ctx:    app.screenContext(),
+		App:    app,
+	}
+	screen.Show()
+}
+
 func (app *App) ShowNewNetworkScreen() {
 	var sn = &screenNew{
 		Window: app.Window,
-		Ctx:    app.Ctx,
+		Ctx:    app.screenContext(),
 		App:    app,
 	}
 	sn.Show()
@@ -97,7 +151,7 @@ func (app *App) ShowNewNetworkScreen() {
 func (app *App) ShowJoinByURLScreen() {
 	var screen = &screenJoinByLink{
 		Window: app.Window,
-		Ctx:    app.Ctx,
+		Ctx:    app.screenContext(),
 		App:    app,
 	}
 
@@ -9,9 +9,14 @@ import (
 	"fyne.io/fyne/widget"
 	"github.com/pkg/browser"
 	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/keys"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/manager"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netns"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
 	"github.com/tinc-boot/tincd/network"
 	"log"
-	"path/filepath"
+	"strings"
+	"sync"
 )
 
 type screenNetwork struct {
@@ -20,6 +25,7 @@ type screenNetwork struct {
 	Ctx     context.Context
 	App     *App
 	toolbar *widget.Toolbar
+	watch   sync.Once
 }
 
 func (sc *screenNetwork) Show() {
@@ -28,21 +34,18 @@ func (sc *screenNetwork) Show() {
 		dialog.NewInformation("Failed", err.Error(), sc.Window).Show()
 		return
 	}
-	var running bool
+	sc.watch.Do(func() {
+		sc.App.watchStates(sc.Ctx, sc.onStateChange)
+	})
+	state, stateErr := sc.App.Pool.State(sc.Network.Name())
+	running := state == manager.Running || state == manager.Degraded
 	action := widget.NewToolbarAction(theme.MediaPlayIcon(), func() {
 		sc.start()
 	})
-	if inst := sc.App.Pool.Find(sc.Network.Name()); inst != nil {
-		select {
-		case <-inst.Done():
-		default:
-			running = true
-		}
-		if running {
-			action = widget.NewToolbarAction(theme.MediaPauseIcon(), func() {
-				sc.stop(inst)
-			})
-		}
+	if state.Active() {
+		action = widget.NewToolbarAction(theme.MediaPauseIcon(), func() {
+			sc.stop()
+		})
 	}
 
 	sc.toolbar = widget.NewToolbar(
@@ -63,10 +66,12 @@ func (sc *screenNetwork) Show() {
 			}
 		}),
 		widget.NewToolbarAction(theme.InfoIcon(), func() {
-			err := browser.OpenFile(filepath.Join(sc.Network.Root, "log.txt"))
-			if err != nil {
-				log.Println("open log file:", err)
-			}
+			sc.App.ShowLogsScreen(sc.Network.Name(), func() {
+				sc.App.ShowNetworkScreen(sc.Network)
+			})
+		}),
+		widget.NewToolbarAction(theme.ContentUndoIcon(), func() {
+			sc.App.ShowHistoryScreen(sc.Network)
 		}),
 		widget.NewToolbarAction(theme.SettingsIcon(), func() {
 			sc.App.ShowNetworkSettingsScreen(sc.Network)
@@ -74,16 +79,45 @@ func (sc *screenNetwork) Show() {
 	)
 	peers := widget.NewVBox()
 
-	sc.Window.SetTitle(sc.Network.Name())
+	ns, err := settings.LoadNetwork(sc.Network)
+	if err != nil {
+		log.Println(sc.Network.Name(), "load settings:", err)
+		ns = settings.DefaultNetwork()
+	}
+	sc.Window.SetTitle(ns.Title(sc.Network))
+
+	stateText := string(state)
+	if stateErr != nil && state == manager.Failed {
+		stateText += ": " + stateErr.Error()
+	}
+
+	var fingerprint = "unknown"
+	if fp, err := keys.FingerprintOf(self.PublicKey); err == nil {
+		fingerprint = fp.Words()
+	}
 
 	var elements = []fyne.CanvasObject{
 		sc.toolbar,
 		widget.NewLabel(config.Name),
+		widget.NewLabel(ns.Description),
 		fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+			widget.NewLabel("State"), widget.NewLabel(stateText),
 			widget.NewLabel("VPN IP"), widget.NewLabel(self.IP),
 			widget.NewLabel("Subnet"), widget.NewLabel(self.Subnet),
+			widget.NewLabel("Fingerprint"), widget.NewLabel(fingerprint),
+			widget.NewLabel("System service"), widget.NewLabel(sc.App.serviceState(sc.Network).String()),
 		),
 	}
+	if sc.App.Settings.Spawner == "namespace" {
+		elements = append(elements, widget.NewLabel("VPN interface is isolated (tincd is in host namespace): ip netns exec "+netns.Name(sc.Network.Name())+" <app>"))
+	}
+	if changed := sc.changedKeys(); len(changed) > 0 {
+		elements = append(elements, widget.NewLabelWithStyle("Keys of verified peers changed: "+strings.Join(changed, ", "),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	}
+	elements = append(elements, widget.NewButtonWithIcon("known peers", theme.InfoIcon(), func() {
+		sc.App.ShowPeersScreen(sc.Network)
+	}))
 	if running {
 		elements = append(elements, widget.NewGroup("Active peers",
 			widget.NewButtonWithIcon("refresh", theme.ViewRefreshIcon(), func() {
@@ -96,16 +130,33 @@ func (sc *screenNetwork) Show() {
 	sc.Window.SetContent(widget.NewVBox(elements...))
 }
 
+// verified peers with changed keys (could be changed by greeting from peers)
+func (sc *screenNetwork) changedKeys() []string {
+	verified, err := keys.LoadVerified(sc.Network)
+	if err != nil {
+		log.Println("load verified peers:", err)
+		return nil
+	}
+	nodes, err := sc.Network.NodesDefinitions()
+	if err != nil {
+		log.Println("list nodes:", err)
+		return nil
+	}
+	var list = make([]*network.Node, len(nodes))
+	for i := range nodes {
+		list[i] = &nodes[i]
+	}
+	return verified.Changed(list)
+}
+
 func (sc *screenNetwork) destroy() {
 	progress := dialog.NewProgressInfinite("Removing", "removing... ", sc.Window)
 	progress.Show()
 
-	if ntw := sc.App.Pool.Find(sc.Network.Name()); ntw != nil {
-		_, _ = ntw.API().Kill(context.Background())
-		<-ntw.Done()
+	err := sc.App.stopNetwork(sc.App.Ctx, sc.Network.Name())
+	if err == nil {
+		err = sc.Network.Destroy()
 	}
-
-	err := sc.Network.Destroy()
 	progress.Hide()
 
 	if err != nil {
@@ -136,7 +187,7 @@ func (sc *screenNetwork) listActivePeers(container *widget.Box) {
 		return
 	}
 
-	grid := layout.NewGridLayout(2)
+	grid := layout.NewGridLayout(3)
 
 	var items []fyne.CanvasObject
 	for _, name := range peers {
@@ -145,13 +196,33 @@ func (sc *screenNetwork) listActivePeers(container *widget.Box) {
 			log.Println(name, err)
 			continue
 		}
-		items = append(items, widget.NewLabel(name), widget.NewLabel(info.IP))
+		var peer = name
+		items = append(items, widget.NewLabel(name), widget.NewLabel(info.IP), widget.NewButtonWithIcon("", theme.SearchIcon(), func() {
+			sc.diagnose(peer)
+		}))
 	}
 
 	container.Children = []fyne.CanvasObject{fyne.NewContainerWithLayout(grid, items...)}
 	container.Refresh()
 }
 
+func (sc *screenNetwork) diagnose(peer string) {
+	ntw := sc.App.Pool.Find(sc.Network.Name())
+	if ntw == nil {
+		return
+	}
+	progress := dialog.NewProgressInfinite("Diagnostics", "checking "+peer+"... ", sc.Window)
+	progress.Show()
+	result, err := ntw.API().Diagnose(sc.Ctx, peer)
+	progress.Hide()
+	if err != nil {
+		log.Println("diagnose", peer, err)
+		dialog.NewInformation("Failed", err.Error(), sc.Window).Show()
+		return
+	}
+	dialog.NewInformation("Diagnostics", result.String(), sc.Window).Show()
+}
+
 func (sc *screenNetwork) start() {
 	if !internal.CanStart() {
 		dialog.NewInformation("Oops", "Please start application as Administrator", sc.Window).Show()
@@ -160,41 +231,31 @@ func (sc *screenNetwork) start() {
 	startingDialog := dialog.NewProgressInfinite("Starting", "starting... ", sc.Window)
 	startingDialog.Show()
 
-	worker, err := sc.App.Pool.SpawnSudoContext(sc.Network.Name())
-
+	_, err := sc.App.startNetwork(sc.Network)
+	startingDialog.Hide()
 	if err != nil {
-		startingDialog.Hide()
 		log.Println("start", sc.Network.Name(), err)
 		dialog.NewInformation("Failed to start", err.Error(), sc.Window).Show()
-		return
 	}
-	startingDialog.Hide()
-	sc.toolbar.Items[2] = widget.NewToolbarAction(theme.MediaPauseIcon(), func() {
-		_, _ = worker.API().Kill(context.Background())
-	})
-	sc.refreshToolbar()
-
-	go func() {
-		<-worker.Done()
-		sc.stop(worker)
-	}()
 }
 
-func (sc *screenNetwork) stop(instance internal.Port) {
+func (sc *screenNetwork) stop() {
 	stoppingDialog := dialog.NewProgressInfinite("Stopping", "stopping...", sc.Window)
-
 	stoppingDialog.Show()
-	log.Println("stop", instance.Name())
-	_, _ = instance.API().Kill(context.Background())
-	<-instance.Done()
-
-	sc.toolbar.Items[2] = widget.NewToolbarAction(theme.MediaPlayIcon(), func() {
-		sc.start()
-	})
+	log.Println("stop", sc.Network.Name())
+	if err := sc.App.stopNetwork(sc.App.Ctx, sc.Network.Name()); err != nil {
+		log.Println("stop", sc.Network.Name(), err)
+	}
 	stoppingDialog.Hide()
-	sc.refreshToolbar()
 }
 
-func (sc *screenNetwork) refreshToolbar() {
+// screen is rendered again on every transition of the network
+func (sc *screenNetwork) onStateChange(event manager.Event) {
+	if event.Network != sc.Network.Name() {
+		return
+	}
 	sc.Show()
+	if event.State == manager.Failed && event.Previous.Active() {
+		dialog.NewInformation("Network stopped", event.Error.Error(), sc.Window).Show()
+	}
 }
@@ -6,8 +6,12 @@ import (
 	"fyne.io/fyne/dialog"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
-	"github.com/tinc-boot/tincd"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/history"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/netlock"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"log"
 	"path/filepath"
+	"time"
 )
 
 type screenNew struct {
@@ -23,7 +27,7 @@ func (sn *screenNew) Show() {
 	netName.PlaceHolder = "network name"
 
 	subnet := widget.NewEntry()
-	subnet.Text = "10.152.0.0/16"
+	subnet.Text = sn.App.Settings.DefaultSubnet
 
 	sn.Window.SetTitle("New network")
 	sn.Window.SetContent(widget.NewVBox(
@@ -47,11 +51,19 @@ func (sn *screenNew) Show() {
 func (sn *screenNew) create(subnet string, netName string) {
 	progress := dialog.NewProgressInfinite("Creating", "creating... ", sn.Window)
 	progress.Show()
-	ntw, err := tincd.Create(filepath.Join(sn.App.Config.ConfigDir, netName), subnet)
+	ntw, err := netlock.Create(filepath.Join(sn.App.Config.ConfigDir, netName), subnet)
 	if err != nil {
 		progress.Hide()
 		dialog.NewInformation("Failed", err.Error(), sn.Window).Show()
 	} else {
+		if err := history.Of(ntw).Save("created"); err != nil {
+			log.Println("save history:", err)
+		}
+		if err := settings.UpdateNetwork(ntw, func(ns *settings.Network) {
+			ns.Created = time.Now()
+		}); err != nil {
+			log.Println("save network settings:", err)
+		}
 		progress.Hide()
 		sn.App.ShowNetworkScreen(ntw)
 	}
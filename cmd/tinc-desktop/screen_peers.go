@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/keys"
+	"github.com/tinc-boot/tincd/network"
+	"log"
+	"strings"
+)
+
+type screenPeers struct {
+	Window  fyne.Window
+	Network *network.Network
+	Ctx     context.Context
+	App     *App
+}
+
+func (sp *screenPeers) Show() {
+	sp.Window.SetTitle(sp.Network.Name() + " peers")
+
+	config, err := sp.Network.Read()
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sp.Window).Show()
+		return
+	}
+	nodes, err := sp.Network.NodesDefinitions()
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sp.Window).Show()
+		return
+	}
+	verified, err := keys.LoadVerified(sp.Network)
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), sp.Window).Show()
+		return
+	}
+
+	var items []fyne.CanvasObject
+	for _, node := range nodes {
+		var cp = node
+		fp, err := keys.FingerprintOf(cp.PublicKey)
+		if err != nil {
+			log.Println(cp.Name, "fingerprint:", err)
+			continue
+		}
+		var action fyne.CanvasObject
+		state := verified.State(cp.Name, fp)
+		if cp.Name == config.Name {
+			action = widget.NewLabel("self")
+		} else if state == keys.Verified {
+			action = widget.NewButtonWithIcon("unverify", theme.CancelIcon(), func() {
+				sp.setVerified(verified, cp.Name, fp, false)
+			})
+		} else {
+			action = widget.NewButtonWithIcon("verify", theme.ConfirmIcon(), func() {
+				sp.verify(verified, &cp, fp)
+			})
+		}
+		title := cp.Name + " (" + cp.IP + ")"
+		if cp.Name != config.Name {
+			title += " - " + state.String()
+		}
+		items = append(items, widget.NewGroup(title,
+			widget.NewLabelWithStyle(fp.Words(), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+			widget.NewHBox(
+				widget.NewButtonWithIcon("fingerprint", theme.InfoIcon(), func() {
+					showFingerprint(cp.Name, fp, sp.Window)
+				}),
+				layout.NewSpacer(),
+				action,
+			),
+		))
+	}
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+			sp.App.ShowNetworkScreen(sp.Network)
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarSpacer(),
+	)
+
+	sp.Window.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(toolbar, nil, nil, nil),
+		toolbar,
+		widget.NewVScrollContainer(widget.NewVBox(items...)),
+	))
+}
+
+func (sp *screenPeers) verify(verified *keys.VerifiedPeers, node *network.Node, fp keys.Fingerprint) {
+	message := "Compare with fingerprint of " + node.Name + " received out-of-band:\n\n" + fp.Words() + "\n\n" + wrapFingerprint(fp)
+	dialog.ShowConfirm("Verify "+node.Name, message, func(ok bool) {
+		if ok {
+			sp.setVerified(verified, node.Name, fp, true)
+		}
+	}, sp.Window)
+}
+
+func (sp *screenPeers) setVerified(verified *keys.VerifiedPeers, node string, fp keys.Fingerprint, mark bool) {
+	if mark {
+		verified.Mark(node, fp)
+	} else {
+		verified.Unmark(node)
+	}
+	if err := verified.Save(); err != nil {
+		dialog.NewInformation("Failed", err.Error(), sp.Window).Show()
+		return
+	}
+	sp.Show()
+}
+
+func showFingerprint(name string, fp keys.Fingerprint, window fyne.Window) {
+	dialog.ShowCustom(name, "Close", widget.NewVBox(
+		widget.NewLabel(fp.Words()),
+		widget.NewLabelWithStyle(wrapFingerprint(fp), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+		widget.NewButtonWithIcon("copy", theme.ContentCopyIcon(), func() {
+			window.Clipboard().SetContent(fp.String())
+		}),
+	), window)
+}
+
+func showChangedKeys(names []string, window fyne.Window) {
+	dialog.NewInformation("Keys changed", "Keys of verified peers changed:\n"+strings.Join(names, "\n")+
+		"\n\nVerify them again before trusting", window).Show()
+}
+
+// fingerprint in two lines to fit small window
+func wrapFingerprint(fp keys.Fingerprint) string {
+	text := fp.String()
+	half := len(fp) / 2 * 3
+	return text[:half] + "\n" + text[half:]
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/autostart"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/logging"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/spawners"
+	"github.com/tinc-boot/tinc-desktop/sudo"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type screenSettings struct {
+	Window fyne.Window
+	Ctx    context.Context
+	App    *App
+}
+
+func (ss *screenSettings) Show() {
+	ss.Window.SetTitle("Settings")
+	var edit = *ss.App.Settings
+
+	subnet := widget.NewEntry()
+	subnet.SetText(edit.DefaultSubnet)
+	subnet.OnChanged = func(s string) {
+		edit.DefaultSubnet = strings.TrimSpace(s)
+	}
+
+	onLogin := widget.NewCheck("start on login", func(v bool) {
+		edit.Autostart = v
+	})
+	onLogin.SetChecked(edit.Autostart)
+
+	helper := widget.NewSelect(append([]string{settings.HelperAuto}, sudo.Helpers()...), func(v string) {
+		edit.PrivilegeHelper = v
+	})
+	helper.SetSelected(edit.PrivilegeHelper)
+
+	spawnerInfo := widget.NewLabel("")
+	spawner := widget.NewSelect(append([]string{settings.SpawnerAuto}, spawners.Names()...), func(v string) {
+		edit.Spawner = v
+		spawnerInfo.SetText(spawnerDescription(v))
+	})
+	spawner.SetSelected(edit.Spawner)
+
+	stopTimeout := widget.NewEntry()
+	stopTimeout.SetText(strconv.Itoa(edit.StopTimeout))
+	stopTimeout.OnChanged = func(s string) {
+		edit.StopTimeout, _ = strconv.Atoi(strings.TrimSpace(s))
+	}
+
+	themeSelect := widget.NewSelect([]string{settings.ThemeDark, settings.ThemeLight}, func(v string) {
+		edit.Theme = v
+	})
+	themeSelect.SetSelected(edit.Theme)
+
+	logLevel := widget.NewSelect([]string{"debug", "info", "warn", "error"}, func(v string) {
+		edit.LogLevel = v
+	})
+	logLevel.SetSelected(edit.LogLevel)
+
+	updates := widget.NewSelect([]string{settings.UpdatesNotify, settings.UpdatesNever}, func(v string) {
+		edit.Updates = v
+	})
+	updates.SetSelected(edit.Updates)
+
+	servers := widget.NewMultiLineEntry()
+	servers.SetPlaceHolder("majordomo servers (one per line)")
+	servers.SetText(strings.Join(edit.MajordomoServers, "\n"))
+	servers.OnChanged = func(s string) {
+		edit.MajordomoServers = nil
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				edit.MajordomoServers = append(edit.MajordomoServers, line)
+			}
+		}
+	}
+
+	ss.Window.SetContent(widget.NewVBox(
+		widget.NewToolbar(
+			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+				ss.App.ShowMainScreen()
+			}),
+			widget.NewToolbarSeparator(),
+			widget.NewToolbarSpacer(),
+			widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+				ss.save(&edit)
+			}),
+		),
+		fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+			widget.NewLabel("Default subnet"), subnet,
+			widget.NewLabel("Privilege helper"), helper,
+			widget.NewLabel("Spawner"), spawner,
+			widget.NewLabel("Stop timeout (s)"), stopTimeout,
+			widget.NewLabel("Theme"), themeSelect,
+			widget.NewLabel("Log level"), logLevel,
+			widget.NewLabel("Updates"), updates,
+		),
+		spawnerInfo,
+		onLogin,
+		servers,
+		widget.NewLabel("Privilege helper and spawner are applied after restart"),
+		widget.NewLabel(detectedHelper()),
+	))
+}
+
+func (ss *screenSettings) save(edit *settings.App) {
+	if _, _, err := net.ParseCIDR(edit.DefaultSubnet); err != nil {
+		dialog.NewInformation("Invalid subnet", err.Error(), ss.Window).Show()
+		return
+	}
+	if edit.StopTimeout <= 0 {
+		dialog.NewInformation("Invalid stop timeout", "timeout should be positive number of seconds", ss.Window).Show()
+		return
+	}
+	if err := edit.Save(ss.App.Config.ConfigDir); err != nil {
+		dialog.NewInformation("Failed", err.Error(), ss.Window).Show()
+		return
+	}
+	*ss.App.Settings = *edit
+	ss.App.Pool.StopTimeout = edit.StopDeadline()
+	ss.App.applyTheme()
+	if err := ss.App.applyAutostart(); err != nil {
+		log.Println("autostart:", err)
+		dialog.NewInformation("Failed configure start on login", err.Error(), ss.Window).Show()
+		return
+	}
+	if level, err := logging.ParseLevel(edit.LogLevel); err == nil {
+		ss.App.Logger.SetLevel(level)
+	}
+	ss.App.ShowMainScreen()
+}
+
+func (app *App) applyTheme() {
+	if app.Settings.Theme == settings.ThemeLight {
+		app.App.Settings().SetTheme(theme.LightTheme())
+	} else {
+		app.App.Settings().SetTheme(theme.DarkTheme())
+	}
+}
+
+// Install or remove start on login entry according to settings
+func (app *App) applyAutostart() error {
+	if !app.Settings.Autostart {
+		if !autostart.Installed() {
+			return nil
+		}
+		return autostart.Uninstall()
+	}
+	entry, err := autostart.Current()
+	if err != nil {
+		return err
+	}
+	return autostart.Install(entry)
+}
+
+func spawnerDescription(name string) string {
+	if name == "" || name == settings.SpawnerAuto {
+		return "Spawner is selected by privileges and platform"
+	}
+	return "Spawner: " + spawners.Description(name)
+}
+
+func detectedHelper() string {
+	backend, err := sudo.Detect()
+	if err != nil {
+		return err.Error()
+	}
+	return "Detected helper: " + backend.Name()
+}
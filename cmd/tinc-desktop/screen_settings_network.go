@@ -4,10 +4,17 @@ import (
 	"context"
 	"fyne.io/fyne"
 	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
 	"fyne.io/fyne/theme"
 	"fyne.io/fyne/widget"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/history"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/keys"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/service"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
 	"github.com/tinc-boot/tincd/network"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 type screenSettingsNetwork struct {
@@ -44,25 +51,282 @@ func (ssn *screenSettingsNetwork) Show() {
 
 	var addressList addressesList
 
-	ssn.Window.SetContent(widget.NewVBox(
-		widget.NewToolbar(
-			widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
-				ssn.App.ShowNetworkScreen(ssn.Network)
-			}),
-			widget.NewToolbarSeparator(),
-			widget.NewToolbarSpacer(),
-			widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
-				ssn.update(config.Port, config.Device, addressList.addresses)
-			}),
-		),
-		widget.NewVBox(
-			port,
-			device,
-			addressList.build(self.Address),
-		),
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.NavigateBackIcon(), func() {
+			ssn.App.ShowNetworkScreen(ssn.Network)
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarSpacer(),
+		widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+			ssn.update(config.Port, config.Device, addressList.addresses)
+		}),
+	)
+
+	ssn.Window.SetContent(fyne.NewContainerWithLayout(layout.NewBorderLayout(toolbar, nil, nil, nil),
+		toolbar,
+		widget.NewVScrollContainer(widget.NewVBox(
+			widget.NewVBox(
+				port,
+				device,
+				addressList.build(self.Address),
+			),
+			ssn.detailsGroup(),
+			ssn.keysGroup(),
+			ssn.debugGroup(),
+			ssn.serviceGroup(),
+		)),
 	))
 }
 
+func (ssn *screenSettingsNetwork) detailsGroup() fyne.CanvasObject {
+	ns, err := settings.LoadNetwork(ssn.Network)
+	if err != nil {
+		return widget.NewGroup("Details", widget.NewLabel(err.Error()))
+	}
+	displayName := widget.NewEntry()
+	displayName.SetPlaceHolder(ssn.Network.Name())
+	displayName.SetText(ns.DisplayName)
+
+	description := widget.NewMultiLineEntry()
+	description.SetPlaceHolder("description")
+	description.SetText(ns.Description)
+
+	var colors []string
+	for name := range settings.Colors {
+		colors = append(colors, name)
+	}
+	sort.Strings(colors)
+	color := widget.NewSelect(colors, nil)
+	color.SetSelected(ns.Color)
+
+	var icons = []string{"none"}
+	for name := range networkIcons {
+		icons = append(icons, name)
+	}
+	sort.Strings(icons[1:])
+	icon := widget.NewSelect(icons, nil)
+	icon.SetSelected("none")
+	if _, ok := networkIcons[ns.Icon]; ok {
+		icon.SetSelected(ns.Icon)
+	}
+
+	tags := widget.NewEntry()
+	tags.SetPlaceHolder("tags (comma separated)")
+	tags.SetText(strings.Join(ns.Tags, ", "))
+
+	autostart := widget.NewCheck("start with application", nil)
+	autostart.SetChecked(ns.Autostart)
+
+	restart := widget.NewSelect([]string{settings.RestartNever, settings.RestartOnFailure}, nil)
+	restart.SetSelected(ns.RestartPolicy)
+
+	var items = []fyne.CanvasObject{
+		displayName,
+		description,
+		fyne.NewContainerWithLayout(layout.NewGridLayout(2),
+			widget.NewLabel("Color"), color,
+			widget.NewLabel("Icon"), icon,
+			widget.NewLabel("Restart"), restart,
+		),
+		tags,
+		autostart,
+	}
+	if ns.JoinURL != "" {
+		items = append(items, widget.NewLabel("Joined by "+ns.JoinURL))
+	}
+	if !ns.Created.IsZero() {
+		items = append(items, widget.NewLabel("Created "+ns.Created.Format("2006-01-02 15:04")))
+	}
+	if !ns.LastConnected.IsZero() {
+		items = append(items, widget.NewLabel("Last connected "+ns.LastConnected.Format("2006-01-02 15:04")))
+	}
+	items = append(items, widget.NewButtonWithIcon("save details", theme.DocumentSaveIcon(), func() {
+		err := settings.UpdateNetwork(ssn.Network, func(ns *settings.Network) {
+			ns.DisplayName = strings.TrimSpace(displayName.Text)
+			ns.Description = strings.TrimSpace(description.Text)
+			ns.Color = color.Selected
+			ns.Icon = icon.Selected
+			ns.Tags = nil
+			for _, tag := range strings.Split(tags.Text, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					ns.Tags = append(ns.Tags, tag)
+				}
+			}
+			ns.Autostart = autostart.Checked
+			ns.RestartPolicy = restart.Selected
+		})
+		if err != nil {
+			dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+			return
+		}
+		ssn.App.ShowNetworkScreen(ssn.Network)
+	}))
+	return widget.NewGroup("Details", items...)
+}
+
+func (ssn *screenSettingsNetwork) debugGroup() fyne.CanvasObject {
+	ns, err := settings.LoadNetwork(ssn.Network)
+	if err != nil {
+		return widget.NewGroup("tincd debug level", widget.NewLabel(err.Error()))
+	}
+	var levels []string
+	for level := settings.MinDebugLevel; level <= settings.MaxDebugLevel; level++ {
+		levels = append(levels, strconv.Itoa(level))
+	}
+	level := widget.NewSelect(levels, nil)
+	level.SetSelected(strconv.Itoa(ns.DebugLevel))
+	level.OnChanged = func(value string) {
+		v, _ := strconv.Atoi(value)
+		ssn.setDebugLevel(v)
+	}
+	return widget.NewGroup("tincd debug level", level)
+}
+
+// save level and apply it to running tincd
+func (ssn *screenSettingsNetwork) setDebugLevel(level int) {
+	if err := settings.UpdateNetwork(ssn.Network, func(ns *settings.Network) {
+		ns.DebugLevel = level
+	}); err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	if err := service.UpdateEnvironment(ssn.App.Config.ConfigDir, ssn.Network.Name(), level); err != nil {
+		dialog.NewInformation("Failed change level of service", err.Error(), ssn.Window).Show()
+	}
+	worker := ssn.App.Pool.Find(ssn.Network.Name())
+	if worker == nil {
+		return
+	}
+	if _, err := worker.API().SetDebugLevel(ssn.Ctx, level); err != nil {
+		dialog.NewInformation("Failed change level of running network", err.Error(), ssn.Window).Show()
+	}
+}
+
+func (ssn *screenSettingsNetwork) serviceGroup() fyne.CanvasObject {
+	state := ssn.App.serviceState(ssn.Network)
+	if state.Installed() {
+		return widget.NewGroup("System service",
+			widget.NewLabel(state.String()),
+			widget.NewButtonWithIcon("remove service", theme.DeleteIcon(), func() {
+				ssn.setService(state.Mode, false)
+			}),
+		)
+	}
+	mode := widget.NewSelect([]string{string(service.ModeWorker), string(service.ModeTincd)}, nil)
+	mode.SetSelected(string(service.ModeWorker))
+	return widget.NewGroup("System service",
+		widget.NewLabel("Run network without desktop session (systemd)"),
+		mode,
+		widget.NewButtonWithIcon("install as service", theme.SettingsIcon(), func() {
+			ssn.setService(service.Mode(mode.Selected), true)
+		}),
+	)
+}
+
+func (ssn *screenSettingsNetwork) setService(mode service.Mode, install bool) {
+	progress := dialog.NewProgressInfinite("System service", "configuring service... ", ssn.Window)
+	progress.Show()
+	err := ssn.App.setService(ssn.Ctx, ssn.Network, mode, install)
+	progress.Hide()
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	ssn.Show()
+}
+
+func (ssn *screenSettingsNetwork) keysGroup() fyne.CanvasObject {
+	pushURL := widget.NewEntry()
+	pushURL.PlaceHolder = "join URL"
+
+	var items []fyne.CanvasObject
+	if keys.Pending(ssn.Network) {
+		items = append(items,
+			widget.NewLabel("New key is not confirmed"),
+			widget.NewHBox(
+				widget.NewButtonWithIcon("confirm", theme.ConfirmIcon(), func() {
+					ssn.confirmKeys()
+				}),
+				widget.NewButtonWithIcon("revert", theme.ContentUndoIcon(), func() {
+					ssn.revertKeys()
+				}),
+			))
+	} else {
+		items = append(items, widget.NewButtonWithIcon("rotate keys", theme.ViewRefreshIcon(), func() {
+			ssn.rotateKeys()
+		}))
+	}
+	items = append(items,
+		widget.NewButtonWithIcon("export host file", theme.ContentCopyIcon(), func() {
+			ssn.exportHost()
+		}),
+		widget.NewHScrollContainer(pushURL),
+		widget.NewButtonWithIcon("push host file", theme.MailSendIcon(), func() {
+			ssn.pushHost(strings.TrimSpace(pushURL.Text))
+		}),
+	)
+	return widget.NewGroup("Keys", items...)
+}
+
+func (ssn *screenSettingsNetwork) rotateKeys() {
+	dialog.ShowConfirm("Rotate keys", "Generate new key pair? Old key will be kept until confirmation", func(ok bool) {
+		if !ok {
+			return
+		}
+		progress := dialog.NewProgressInfinite("Rotating", "generating keys... ", ssn.Window)
+		progress.Show()
+		_, err := rotateKeys(ssn.Network)
+		progress.Hide()
+		if err != nil {
+			dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+			return
+		}
+		ssn.App.ApplyToRunning(ssn.Network, applyRestart)
+	}, ssn.Window)
+}
+
+func (ssn *screenSettingsNetwork) confirmKeys() {
+	if err := keys.Confirm(ssn.Network); err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	ssn.Show()
+}
+
+func (ssn *screenSettingsNetwork) revertKeys() {
+	if err := revertKeys(ssn.Network); err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	ssn.App.ApplyToRunning(ssn.Network, applyRestart)
+}
+
+func (ssn *screenSettingsNetwork) exportHost() {
+	data, err := exportSelf(ssn.Network)
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	ssn.Window.Clipboard().SetContent(string(data))
+	dialog.NewInformation("Exported", "Host file copied to clipboard", ssn.Window).Show()
+}
+
+func (ssn *screenSettingsNetwork) pushHost(url string) {
+	progress := dialog.NewProgressInfinite("Pushing", "pushing host file... ", ssn.Window)
+	progress.Show()
+	changed, err := pushSelf(ssn.Ctx, ssn.Network, url)
+	progress.Hide()
+	if err != nil {
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+	if len(changed) > 0 {
+		showChangedKeys(changed, ssn.Window)
+		return
+	}
+	dialog.NewInformation("Pushed", "Host file pushed", ssn.Window).Show()
+}
+
 func (ssn *screenSettingsNetwork) update(port uint16, device string, addreses []*network.Address) {
 	var addrs = make([]network.Address, 0, len(addreses))
 	for _, a := range addreses {
@@ -72,10 +336,21 @@ func (ssn *screenSettingsNetwork) update(port uint16, device string, addreses []
 	updateDialog := dialog.NewProgressInfinite("Updating", "updating... ", ssn.Window)
 	updateDialog.Show()
 
-	err := ssn.Network.Upgrade(network.Upgrade{
+	self, config, err := ssn.Network.SelfConfig()
+	if err != nil {
+		updateDialog.Hide()
+		dialog.NewInformation("Failed", err.Error(), ssn.Window).Show()
+		return
+	}
+
+	upgrade := network.Upgrade{
 		Port:    port,
 		Address: addrs,
 		Device:  device,
+	}
+
+	err = history.Of(ssn.Network).Track("settings updated", func() error {
+		return ssn.Network.Upgrade(upgrade)
 	})
 	if err != nil {
 		updateDialog.Hide()
@@ -83,5 +358,5 @@ func (ssn *screenSettingsNetwork) update(port uint16, device string, addreses []
 		return
 	}
 	updateDialog.Hide()
-	ssn.App.ShowNetworkScreen(ssn.Network)
+	ssn.App.ApplyToRunning(ssn.Network, classifyUpgrade(self, config, upgrade))
 }
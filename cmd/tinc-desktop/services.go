@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/service"
+	"github.com/tinc-boot/tinc-desktop/sudo"
+	"github.com/tinc-boot/tincd/network"
+	"os"
+	"strings"
+)
+
+// State of system service of network: mode, active state and enabled flag. Empty mode if service not installed
+type serviceState struct {
+	Mode    service.Mode
+	State   string
+	Enabled bool
+}
+
+func (ss *serviceState) Installed() bool { return ss.Mode != "" }
+
+func (ss *serviceState) String() string {
+	if !ss.Installed() {
+		return "not installed"
+	}
+	return "service " + ss.State + " (" + string(ss.Mode) + ")"
+}
+
+func (app *App) serviceState(ntw *network.Network) *serviceState {
+	for _, mode := range []service.Mode{service.ModeWorker, service.ModeTincd} {
+		unit := &service.Unit{Mode: mode}
+		state, enabled, err := service.Status(unit, ntw.Name())
+		if err != nil {
+			return &serviceState{}
+		}
+		if enabled || (state != "" && state != "inactive" && state != "unknown") {
+			return &serviceState{Mode: mode, State: state, Enabled: enabled}
+		}
+	}
+	return &serviceState{}
+}
+
+// Install or remove system service of network by elevated service command. Running worker is stopped before
+// installation because network device can't be shared
+func (app *App) setService(ctx context.Context, ntw *network.Network, mode service.Mode, install bool) error {
+	if install {
+		if err := app.stopNetwork(ctx, ntw.Name()); err != nil {
+			return err
+		}
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	action := "--uninstall"
+	if install {
+		action = "--install"
+	}
+	cmd, err := sudo.Command(app.Settings.PrivilegeHelper, []string{executable, "-c", app.Config.ConfigDir, "-n", ntw.Name(), "service", action, "--mode", string(mode)})
+	if err != nil {
+		return err
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fyne.io/fyne/dialog"
+	"github.com/pkg/browser"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/settings"
+	"github.com/tinc-boot/tinc-desktop/cmd/tinc-desktop/internal/updates"
+	"log"
+	"time"
+)
+
+const updateCheckTimeout = 10 * time.Second
+
+// Notify about newer release if it is allowed by settings
+func (app *App) checkUpdates() {
+	if app.Settings.Updates != settings.UpdatesNotify {
+		return
+	}
+	ctx, cancel := context.WithTimeout(app.Ctx, updateCheckTimeout)
+	defer cancel()
+	release, err := updates.Latest(ctx)
+	if err != nil {
+		log.Println("check updates:", err)
+		return
+	}
+	if !release.Newer(version) {
+		return
+	}
+	dialog.NewConfirm("Update available", "Version "+release.Version+" is available (installed "+version+").\nOpen download page?", func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := browser.OpenURL(release.URL); err != nil {
+			dialog.NewInformation("Failed", err.Error(), app.Window).Show()
+		}
+	}, app.Window).Show()
+}
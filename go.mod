@@ -7,6 +7,6 @@ require (
 	github.com/jessevdk/go-flags v1.4.1-0.20181221193153-c0795c8afcf4
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
 	github.com/reddec/jsonrpc2 v0.1.18-0.20200514125425-e010095d0a08
-	github.com/theckman/go-flock v0.7.1 // indirect
+	github.com/theckman/go-flock v0.7.1
 	github.com/tinc-boot/tincd v0.0.0-20200518124055-c1f9617bc3f1
 )
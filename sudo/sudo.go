@@ -0,0 +1,135 @@
+package sudo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Auto-detection of privilege escalation backend
+const Auto = "auto"
+
+var ErrNoBackend = errors.New("no privilege escalation helper available")
+
+// Way to run command as root
+type Backend interface {
+	Name() string
+	// backend could be used in current environment
+	Available() bool
+	Command(args []string) *exec.Cmd
+}
+
+// Names of privilege escalation backends supported on the platform
+func Helpers() []string {
+	var ans []string
+	for _, backend := range backends {
+		ans = append(ans, backend.Name())
+	}
+	return ans
+}
+
+// First available backend in order of preference
+func Detect() (Backend, error) {
+	var tried []string
+	for _, backend := range backends {
+		if backend.Available() {
+			return backend, nil
+		}
+		tried = append(tried, backend.Name())
+	}
+	return nil, fmt.Errorf("%w (tried %s), install polkit (pkexec) or set SUDO_ASKPASS", ErrNoBackend, strings.Join(tried, ", "))
+}
+
+// Add backend which is preferred over platform ones (custom helpers and tests). Returned function restores
+// previous backends
+func Register(backend Backend) func() {
+	previous := backends
+	backends = append([]Backend{backend}, backends...)
+	return func() { backends = previous }
+}
+
+// Find backend by name. Empty name or Auto means detection
+func Find(name string) (Backend, error) {
+	if name == "" || name == Auto {
+		return Detect()
+	}
+	for _, backend := range backends {
+		if backend.Name() != name {
+			continue
+		}
+		if !backend.Available() {
+			return nil, fmt.Errorf("privilege escalation helper %s is not available", name)
+		}
+		return backend, nil
+	}
+	return nil, fmt.Errorf("unknown privilege escalation helper %s", name)
+}
+
+// Command to run args as root by specified helper (empty or Auto for detection)
+func Command(helper string, args []string) (*exec.Cmd, error) {
+	if isRoot() {
+		return exec.Command(args[0], args[1:]...), nil
+	}
+	backend, err := Find(helper)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Command(args), nil
+}
+
+// Backend which prefixes arguments by command line
+type prefixBackend struct {
+	name   string
+	binary string
+	prefix []string
+	check  func() bool // additional availability check
+	env    func() []string
+}
+
+func (pb *prefixBackend) Name() string { return pb.name }
+
+func (pb *prefixBackend) Available() bool {
+	if _, err := exec.LookPath(pb.binary); err != nil {
+		return false
+	}
+	return pb.check == nil || pb.check()
+}
+
+func (pb *prefixBackend) Command(args []string) *exec.Cmd {
+	cmd := exec.Command(pb.binary, append(append([]string{}, pb.prefix...), args...)...)
+	if pb.env != nil {
+		cmd.Env = pb.env()
+	}
+	return cmd
+}
+
+// Backend which builds whole command line from arguments
+type wrapBackend struct {
+	name string
+	wrap func(args []string) []string
+}
+
+func (wb *wrapBackend) Name() string    { return wb.name }
+func (wb *wrapBackend) Available() bool { return true }
+
+func (wb *wrapBackend) Command(args []string) *exec.Cmd {
+	cmdline := wb.wrap(args)
+	return exec.Command(cmdline[0], cmdline[1:]...)
+}
+
+// Readable reason of failed escalated command for known exit codes of helpers, original error otherwise
+func Reason(cmd *exec.Cmd, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || filepath.Base(cmd.Path) != "pkexec" {
+		return err
+	}
+	switch exitErr.ExitCode() {
+	case 126:
+		return fmt.Errorf("%w: authentication dialog dismissed", err)
+	case 127:
+		return fmt.Errorf("%w: not authorized (polkit agent is not running or access denied)", err)
+	}
+	return err
+}
@@ -1,10 +1,13 @@
 package sudo
 
 import (
+	"os"
 	"strconv"
 	"strings"
 )
 
+var backends = []Backend{&wrapBackend{name: "osascript", wrap: WithSudo}}
+
 func WithSudo(args []string) []string {
 	var escaped []string
 	for _, arg := range args {
@@ -15,3 +18,7 @@ func WithSudo(args []string) []string {
 
 	return []string{"osascript", "-e", cli}
 }
+
+func isRoot() bool {
+	return os.Geteuid() == 0
+}
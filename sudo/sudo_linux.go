@@ -1,29 +1,63 @@
 package sudo
 
-import "os/exec"
+import (
+	"os"
+	"os/exec"
+)
 
-var queries = []string{
-	"gksu",
-	"gksudo",
-	"kdesu",
-	"kdesudo",
+// known askpass helpers used if SUDO_ASKPASS is not set
+var askpass = []string{
+	"ssh-askpass",
+	"ksshaskpass",
+	"lxqt-openssh-askpass",
+	"/usr/lib/ssh/ssh-askpass",
+	"/usr/libexec/openssh/gnome-ssh-askpass",
+	"/usr/libexec/openssh/ssh-askpass",
 }
 
+// in order of preference
+var backends = []Backend{
+	// polkit shows graphical prompt by agent of desktop session (see assets/linux for action file)
+	&prefixBackend{name: "pkexec", binary: "pkexec", check: graphical},
+	&prefixBackend{name: "sudo-askpass", binary: "sudo", prefix: []string{"-A", "--"}, check: func() bool {
+		return graphical() && askpassHelper() != ""
+	}, env: func() []string {
+		return append(os.Environ(), "SUDO_ASKPASS="+askpassHelper())
+	}},
+	&prefixBackend{name: "gksu", binary: "gksu", prefix: []string{"--"}},
+	&prefixBackend{name: "gksudo", binary: "gksudo", prefix: []string{"--"}},
+	&prefixBackend{name: "kdesu", binary: "kdesu", prefix: []string{"--"}},
+	&prefixBackend{name: "kdesudo", binary: "kdesudo", prefix: []string{"--"}},
+	// there is no terminal for password prompt, so only passwordless rules work
+	&prefixBackend{name: "doas", binary: "doas", prefix: []string{"-n", "--"}},
+	&prefixBackend{name: "sudo", binary: "sudo", prefix: []string{"-n", "--"}},
+}
+
+// Arguments to run command as root by first available helper. Kept for compatibility, use Command
 func WithSudo(args []string) []string {
-	var sudo = "sudo"
-	var dash bool
-	for _, name := range queries {
-		if _, err := exec.LookPath(name); err == nil {
-			sudo = name
-			dash = true
-			break
-		}
+	cmd, err := Command(Auto, args)
+	if err != nil {
+		return append([]string{"sudo", "-n", "--"}, args...)
 	}
-	var ans []string
-	ans = append(ans, sudo)
-	if dash {
-		ans = append(ans, "--")
+	return cmd.Args
+}
+
+func graphical() bool {
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+func askpassHelper() string {
+	if helper := os.Getenv("SUDO_ASKPASS"); helper != "" {
+		return helper
+	}
+	for _, name := range askpass {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
 	}
-	ans = append(ans, args...)
-	return ans
+	return ""
+}
+
+func isRoot() bool {
+	return os.Geteuid() == 0
 }
@@ -5,6 +5,8 @@ import (
 	"strings"
 )
 
+var backends = []Backend{&wrapBackend{name: "runas", wrap: WithSudo}}
+
 func WithSudo(args []string) []string {
 	var escaped []string
 
@@ -14,3 +16,8 @@ func WithSudo(args []string) []string {
 
 	return []string{"runas", "/user:administrator", strconv.Quote(strings.Join(escaped, " "))}
 }
+
+// elevation is checked by manifest of application
+func isRoot() bool {
+	return false
+}